@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+const (
+	// barrierTTL is how long a release stays good for, so a /wait that
+	// arrives just after its /release still goes through.
+	barrierTTL = 30 * time.Second
+
+	// barrierPoll is how often a waiting request checks for its release.
+	// Requests don't share memory at the edge, so the release can only be
+	// seen through the state store.
+	barrierPoll = 100 * time.Millisecond
+)
+
+// barrierID returns the {id} of /wait/{id} or /release/{id}.
+func barrierID(w fsthttp.ResponseWriter, r *fsthttp.Request) (string, bool) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) != 3 || parts[2] == "" {
+		fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
+		return "", false
+	}
+	return parts[2], true
+}
+
+// handleWait blocks until /release/{id} is called for the same id, for up to
+// a minute, then returns a 200. It returns a 504 if no release arrives.
+func handleWait(ctx context.Context, w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	id, ok := barrierID(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store, max-age=0")
+
+	start := time.Now()
+	deadline := time.After(maxDelay())
+	ticker := time.NewTicker(barrierPoll)
+	defer ticker.Stop()
+	for {
+		if released(id) {
+			// Consume the release so the next wait on the id blocks again.
+			storeState("barrier/"+id, "", 0)
+			writeJSON(w, r, fsthttp.StatusOK, map[string]interface{}{
+				"id":     id,
+				"waited": time.Since(start).Seconds(),
+			})
+			return
+		}
+		select {
+		case <-ctx.Done():
+			w.WriteHeader(499)
+			return
+		case <-deadline:
+			fsthttp.Error(w, fsthttp.StatusText(fsthttp.StatusGatewayTimeout), fsthttp.StatusGatewayTimeout)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// handleRelease lets the request waiting on /wait/{id} return.
+func handleRelease(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	id, ok := barrierID(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store, max-age=0")
+
+	if err := storeState("barrier/"+id, "released", barrierTTL); err != nil {
+		fsthttp.Error(w, err.Error(), fsthttp.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, r, fsthttp.StatusOK, map[string]interface{}{"id": id, "released": true})
+}
+
+// released reports whether id has a release that hasn't expired yet.
+func released(id string) bool {
+	_, ok := loadState("barrier/" + id)
+	return ok
+}
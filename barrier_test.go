@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestBarrier(t *testing.T) {
+	done := make(chan *responseRecorder)
+	go func() { done <- serve(t, "GET", "/wait/test-barrier", nil) }()
+
+	select {
+	case rec := <-done:
+		t.Fatalf("wait returned %d before the release", rec.status)
+	case <-time.After(3 * barrierPoll):
+	}
+
+	if rec := serve(t, "POST", "/release/test-barrier", nil); rec.status != 200 {
+		t.Fatalf("release: status %d, want 200", rec.status)
+	}
+	select {
+	case rec := <-done:
+		var body struct {
+			ID     string  `json:"id"`
+			Waited float64 `json:"waited"`
+		}
+		if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if rec.status != 200 || body.ID != "test-barrier" || body.Waited < (3*barrierPoll).Seconds() {
+			t.Errorf("wait: status %d, body %+v", rec.status, body)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("wait didn't return after the release")
+	}
+
+	// The release was consumed, so the next wait blocks until the cap.
+	fakeConfig(t, map[string]string{"delay_max": "1"})
+	if rec := serve(t, "GET", "/wait/test-barrier", nil); rec.status != 504 {
+		t.Errorf("second wait: status %d, want 504", rec.status)
+	}
+}
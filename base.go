@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"strings"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// handleBase64 serves /base64/{value}, /base64/decode/{value} and
+// /base64/encode/{value}. Encoding uses the URL-safe alphabet so the result
+// can be passed straight back in a path; decoding accepts either alphabet.
+func handleBase64(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	serveBaseN(w, r, "/base64/", base64.URLEncoding.EncodeToString, decodeBase64)
+}
+
+// decodeBase64 decodes s in either alphabet, with or without padding.
+func decodeBase64(s string) ([]byte, error) {
+	var err error
+	for _, enc := range []*base64.Encoding{
+		base64.URLEncoding, base64.StdEncoding, base64.RawURLEncoding, base64.RawStdEncoding,
+	} {
+		var b []byte
+		if b, err = enc.DecodeString(s); err == nil {
+			return b, nil
+		}
+	}
+	return nil, err
+}
+
+// handleBase32 serves /base32/{value}, /base32/decode/{value} and
+// /base32/encode/{value}.
+func handleBase32(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	serveBaseN(w, r, "/base32/", base32.StdEncoding.EncodeToString, func(s string) ([]byte, error) {
+		return base32.StdEncoding.DecodeString(strings.ToUpper(s))
+	})
+}
+
+func serveBaseN(w fsthttp.ResponseWriter, r *fsthttp.Request, prefix string, encode func([]byte) string, decode func(string) ([]byte, error)) {
+	value := strings.TrimPrefix(r.URL.Path, prefix)
+	mode := "decode"
+	if i := strings.IndexByte(value, '/'); i >= 0 && (value[:i] == "encode" || value[:i] == "decode") {
+		mode, value = value[:i], value[i+1:]
+	}
+	if value == "" {
+		fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if mode == "encode" {
+		w.Write([]byte(encode([]byte(value))))
+		return
+	}
+
+	data, err := decode(value)
+	if err != nil {
+		fsthttp.Error(w, "Incorrect encoded value: "+err.Error(), fsthttp.StatusBadRequest)
+		return
+	}
+	w.Write(data)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestBaseNRoundTrip(t *testing.T) {
+	const value = "hello, world?"
+	for _, prefix := range []string{"/base64/", "/base32/"} {
+		rec := serve(t, "GET", prefix+"encode/"+url.PathEscape(value), nil)
+		if rec.status != 200 {
+			t.Fatalf("%sencode: status %d", prefix, rec.status)
+		}
+		encoded := rec.body.String()
+
+		rec = serve(t, "GET", prefix+"decode/"+encoded, nil)
+		if rec.status != 200 || rec.body.String() != value {
+			t.Errorf("%sdecode/%s: status %d, body %q, want %q", prefix, encoded, rec.status, rec.body.String(), value)
+		}
+	}
+}
+
+func TestBaseNInvalid(t *testing.T) {
+	for _, target := range []string{"/base32/not-base32!", "/base64/not*base64"} {
+		if rec := serve(t, "GET", target, nil); rec.status != 400 {
+			t.Errorf("GET %s: status %d, want 400", target, rec.status)
+		}
+	}
+}
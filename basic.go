@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// handleBasicAuth challenges for HTTP Basic Auth, succeeding once the
+// request carries the user and password given in the path. Without them in
+// the path, the credentials are checked against the basic_auth_users secret
+// instead.
+func handleBasicAuth(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	basicAuth(w, r, false)
+}
+
+// handleHiddenBasicAuth is /basic-auth for a server that hides protected
+// resources: failures are a 404 with no challenge.
+func handleHiddenBasicAuth(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	basicAuth(w, r, true)
+}
+
+func basicAuth(w fsthttp.ResponseWriter, r *fsthttp.Request, hidden bool) {
+	var check func(user, passwd string) bool
+	switch parts := strings.Split(r.URL.Path, "/"); len(parts) {
+	case 2:
+		users, ok := secretValue("basic_auth_users")
+		if !ok {
+			fsthttp.Error(w, "Basic Auth users aren't configured", fsthttp.StatusServiceUnavailable)
+			return
+		}
+		check = func(user, passwd string) bool { return secretUserMatches(users, user, passwd) }
+	case 4:
+		check = func(user, passwd string) bool { return user == parts[2] && passwd == parts[3] }
+	default:
+		fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
+		return
+	}
+
+	user, passwd, ok := basicCredentials(r)
+	if !ok || !check(user, passwd) {
+		if hidden {
+			fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
+			return
+		}
+		w.Header().Set("WWW-Authenticate", `Basic realm="edgehttpbin"`)
+		w.WriteHeader(fsthttp.StatusUnauthorized)
+		return
+	}
+
+	writeJSON(w, r, fsthttp.StatusOK, map[string]interface{}{"authenticated": true, "user": user})
+}
+
+// secretUserMatches reports whether users, one user:password pair per line,
+// has the given pair. Passwords are compared in constant time.
+func secretUserMatches(users []byte, user, passwd string) bool {
+	for _, line := range strings.Split(string(users), "\n") {
+		kv := strings.SplitN(strings.TrimSpace(line), ":", 2)
+		if len(kv) == 2 && kv[0] == user &&
+			subtle.ConstantTimeCompare([]byte(kv[1]), []byte(passwd)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// basicCredentials returns the user and password of a Basic Authorization
+// header, as described in RFC 7617.
+func basicCredentials(r *fsthttp.Request) (user, passwd string, ok bool) {
+	fields := strings.Fields(r.Header.Get("Authorization"))
+	if len(fields) != 2 || !strings.EqualFold(fields[0], "Basic") {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", "", false
+	}
+	kv := strings.SplitN(string(decoded), ":", 2)
+	if len(kv) != 2 {
+		return "", "", false
+	}
+	return kv[0], kv[1], true
+}
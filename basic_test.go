@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func basicAuthorization(credentials string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+}
+
+// basicAuthCases are the Authorization headers tried against
+// /basic-auth/user/passwd and /hidden-basic-auth/user/passwd.
+var basicAuthCases = []struct {
+	name, auth string
+	ok         bool
+}{
+	{"no credentials", "", false},
+	{"right credentials", basicAuthorization("user:passwd"), true},
+	{"wrong password", basicAuthorization("user:wrong"), false},
+	{"wrong user", basicAuthorization("other:passwd"), false},
+	{"malformed base64", "Basic not*base64", false},
+	{"no colon", basicAuthorization("userpasswd"), false},
+	{"other scheme", "Bearer " + base64.StdEncoding.EncodeToString([]byte("user:passwd")), false},
+	{"extra field", basicAuthorization("user:passwd") + " extra", false},
+}
+
+func TestBasicAuth(t *testing.T) {
+	for _, tt := range basicAuthCases {
+		rec := serve(t, "GET", "/basic-auth/user/passwd", nil, "Authorization", tt.auth)
+		if tt.ok {
+			if rec.status != 200 {
+				t.Errorf("%s: status %d, want 200", tt.name, rec.status)
+			}
+			continue
+		}
+		if rec.status != 401 {
+			t.Errorf("%s: status %d, want 401", tt.name, rec.status)
+		}
+		if got := rec.header.Get("WWW-Authenticate"); got != `Basic realm="edgehttpbin"` {
+			t.Errorf("%s: WWW-Authenticate %q", tt.name, got)
+		}
+	}
+}
+
+func TestHiddenBasicAuth(t *testing.T) {
+	for _, tt := range basicAuthCases {
+		rec := serve(t, "GET", "/hidden-basic-auth/user/passwd", nil, "Authorization", tt.auth)
+		want := 404
+		if tt.ok {
+			want = 200
+		}
+		if rec.status != want {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.status, want)
+		}
+		if got := rec.header.Get("WWW-Authenticate"); got != "" {
+			t.Errorf("%s: WWW-Authenticate %q, want none", tt.name, got)
+		}
+	}
+}
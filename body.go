@@ -0,0 +1,86 @@
+package main
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+const defaultMaxBody = 1 << 20
+
+var (
+	maxBodyOnce  sync.Once
+	maxBodyValue int
+)
+
+// maxBody returns the largest request body, after decoding, that endpoints
+// will read, from the body_max config key. It also bounds how much a
+// compressed body may expand to, so a small zip bomb can't exhaust the
+// instance's memory.
+func maxBody() int {
+	maxBodyOnce.Do(func() {
+		maxBodyValue = configInt("body_max", defaultMaxBody)
+	})
+	return maxBodyValue
+}
+
+var errBodyTooLarge = errors.New("request body too large")
+
+// decodedBody returns the request body with any gzip or deflate
+// Content-Encoding removed.
+func decodedBody(r *fsthttp.Request) (io.Reader, error) {
+	// Requests built in-process, such as those of /self-test, may have no
+	// body at all.
+	if r.Body == nil {
+		return strings.NewReader(""), nil
+	}
+	switch enc := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); enc {
+	case "", "identity":
+		return r.Body, nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r.Body)
+	case "deflate":
+		return zlib.NewReader(r.Body)
+	default:
+		return nil, fmt.Errorf("unsupported Content-Encoding %s", enc)
+	}
+}
+
+// readBody reads the decoded request body, failing with errBodyTooLarge
+// once it grows past maxBody.
+func readBody(r *fsthttp.Request) ([]byte, error) {
+	body, err := decodedBody(r)
+	if err != nil {
+		return nil, err
+	}
+	return readLimited(body, maxBody())
+}
+
+// readLimited reads all of rd, failing with errBodyTooLarge if there is more
+// than limit bytes. It stops reading one byte past the limit.
+func readLimited(rd io.Reader, limit int) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(rd, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > limit {
+		return nil, errBodyTooLarge
+	}
+	return data, nil
+}
+
+// bodyError writes the response for an error returned by readBody or
+// readLimited.
+func bodyError(w fsthttp.ResponseWriter, err error) {
+	if errors.Is(err, errBodyTooLarge) {
+		fsthttp.Error(w, err.Error(), fsthttp.StatusRequestEntityTooLarge)
+		return
+	}
+	fsthttp.Error(w, err.Error(), fsthttp.StatusBadRequest)
+}
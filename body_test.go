@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompressedBody(t *testing.T) {
+	const doc = `{"name":"edgehttpbin","count":3}`
+	encoders := map[string]func(io.Writer) io.WriteCloser{
+		"gzip":    func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+	}
+	for coding, newWriter := range encoders {
+		var buf bytes.Buffer
+		zw := newWriter(&buf)
+		zw.Write([]byte(doc))
+		zw.Close()
+
+		for _, target := range []string{"/post", "/anything"} {
+			rec := serve(t, "POST", target, bytes.NewReader(buf.Bytes()), "Content-Encoding", coding, "Content-Type", "application/json")
+			if rec.status != 200 {
+				t.Fatalf("%s %s: status %d", coding, target, rec.status)
+			}
+			var body echoInfo
+			if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]interface{}{"name": "edgehttpbin", "count": 3.0}
+			if !reflect.DeepEqual(body.JSON, want) || body.Data != doc {
+				t.Errorf("%s %s: json %v, data %q", coding, target, body.JSON, body.Data)
+			}
+		}
+	}
+}
+
+func TestCompressedBodyLimit(t *testing.T) {
+	fakeConfig(t, map[string]string{"body_max": "1024"})
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(make([]byte, 4096))
+	zw.Close()
+
+	rec := serve(t, "POST", "/post", &buf, "Content-Encoding", "gzip")
+	if rec.status != 413 {
+		t.Errorf("status %d, want 413", rec.status)
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	fakeConfig(t, map[string]string{"body_max": "16"})
+	for _, target := range []string{"/post", "/anything", "/delay/0"} {
+		if rec := serve(t, "POST", target, strings.NewReader(strings.Repeat("a", 16))); rec.status != 200 {
+			t.Errorf("POST %s at the limit: status %d, want 200", target, rec.status)
+		}
+		if rec := serve(t, "POST", target, strings.NewReader(strings.Repeat("a", 17))); rec.status != 413 {
+			t.Errorf("POST %s over the limit: status %d, want 413", target, rec.status)
+		}
+	}
+}
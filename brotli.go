@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/andybalholm/brotli"
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+const defaultBrotliQuality = 5
+
+// brotliSampleRepeat is how many copies of the sample page make up the
+// payload /brotli compresses, enough for the quality levels to differ.
+const brotliSampleRepeat = 32
+
+// handleBrotli returns a fixed sample payload brotli encoded at ?quality=,
+// reporting the sizes before and after compression so ratios can be compared.
+func handleBrotli(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	quality := defaultBrotliQuality
+	if param := r.URL.Query().Get("quality"); param != "" {
+		var err error
+		quality, err = strconv.Atoi(param)
+		if err != nil || quality < brotli.BestSpeed || quality > brotli.BestCompression {
+			fsthttp.Error(w, "Invalid quality, must be between 0 and 11", fsthttp.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := staticAssets.ReadFile("static/moby.html")
+	if err != nil {
+		fsthttp.Error(w, fsthttp.StatusText(500), 500)
+		return
+	}
+	sample := bytes.Repeat(page, brotliSampleRepeat)
+
+	var buf bytes.Buffer
+	bw := brotli.NewWriterLevel(&buf, quality)
+	bw.Write(sample)
+	if err := bw.Close(); err != nil {
+		fsthttp.Error(w, err.Error(), fsthttp.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Encoding", "br")
+	w.Header().Set("X-Uncompressed-Length", strconv.Itoa(len(sample)))
+	w.Header().Set("X-Compressed-Length", strconv.Itoa(buf.Len()))
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestBrotliQuality(t *testing.T) {
+	sizes := map[string]int{}
+	for _, quality := range []string{"0", "11"} {
+		rec := serve(t, "GET", "/brotli?quality="+quality, nil)
+		if rec.status != 200 || rec.header.Get("Content-Encoding") != "br" {
+			t.Fatalf("quality %s: status %d, Content-Encoding %q", quality, rec.status, rec.header.Get("Content-Encoding"))
+		}
+		data, err := io.ReadAll(brotli.NewReader(bytes.NewReader(rec.body.Bytes())))
+		if err != nil {
+			t.Fatalf("quality %s: %v", quality, err)
+		}
+		if got := rec.header.Get("X-Uncompressed-Length"); got != strconv.Itoa(len(data)) {
+			t.Errorf("quality %s: X-Uncompressed-Length %s, decompressed %d bytes", quality, got, len(data))
+		}
+		if got := rec.header.Get("X-Compressed-Length"); got != strconv.Itoa(rec.body.Len()) {
+			t.Errorf("quality %s: X-Compressed-Length %s, body %d bytes", quality, got, rec.body.Len())
+		}
+		sizes[quality] = rec.body.Len()
+	}
+	if sizes["11"] >= sizes["0"] {
+		t.Errorf("quality 11 is %d bytes, quality 0 is %d", sizes["11"], sizes["0"])
+	}
+
+	for _, quality := range []string{"-1", "12", "best"} {
+		if rec := serve(t, "GET", "/brotli?quality="+quality, nil); rec.status != 400 {
+			t.Errorf("quality %s: status %d, want 400", quality, rec.status)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+const defaultMaxBytes = 100 * 1024
+
+var (
+	maxBytesOnce  sync.Once
+	maxBytesValue int
+)
+
+// maxBytes returns the largest body /bytes will generate, read once from the
+// bytes_max config key.
+func maxBytes() int {
+	maxBytesOnce.Do(func() {
+		maxBytesValue = configInt("bytes_max", defaultMaxBytes)
+	})
+	return maxBytesValue
+}
+
+func handleBytes(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) != 3 {
+		fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
+		return
+	}
+
+	numBytes, err := strconv.Atoi(parts[2])
+	if err != nil {
+		fsthttp.Error(w, err.Error(), fsthttp.StatusBadRequest)
+		return
+	}
+
+	if numBytes < 0 {
+		fsthttp.Error(w, "Bad Request", fsthttp.StatusBadRequest)
+		return
+	}
+
+	// Special case 0 bytes and exit early, since streaming & chunk size do not
+	// matter here.
+	if numBytes == 0 {
+		w.Header().Set("Content-Length", "0")
+		w.WriteHeader(fsthttp.StatusOK)
+		return
+	}
+
+	if limit := maxBytes(); numBytes > limit {
+		numBytes = limit
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	contentType := "application/octet-stream"
+	switch format {
+	case "":
+	case "hex", "base64":
+		contentType = "text/plain; charset=utf-8"
+	default:
+		fsthttp.Error(w, "Invalid format, must be hex or base64", fsthttp.StatusBadRequest)
+		return
+	}
+	if param := query.Get("content-type"); param != "" {
+		contentType = param
+	}
+
+	// A seed makes the output deterministic, and so worth an ETag and a
+	// cache entry. Without one every response differs, so none is kept.
+	rng := rand.Intn
+	w.Header().Set("Cache-Control", "no-store, max-age=0")
+	if seedParam := query.Get("seed"); seedParam != "" {
+		seed, err := strconv.ParseInt(seedParam, 10, 64)
+		if err != nil {
+			fsthttp.Error(w, "Invalid seed", fsthttp.StatusBadRequest)
+			return
+		}
+		rng = rand.New(rand.NewSource(seed)).Intn
+
+		etag := fmt.Sprintf(`"bytes-%d-%d"`, numBytes, seed)
+		if format != "" {
+			etag = fmt.Sprintf(`"bytes-%d-%d-%s"`, numBytes, seed, format)
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		if !checkConditional(w, r, etag) {
+			return
+		}
+	}
+
+	data := make([]byte, numBytes)
+	for i := range data {
+		data[i] = byte(rng(256))
+	}
+
+	// The encoded formats still carry n bytes once decoded.
+	switch format {
+	case "hex":
+		data = []byte(hex.EncodeToString(data))
+	case "base64":
+		data = []byte(base64.StdEncoding.EncodeToString(data))
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
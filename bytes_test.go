@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"testing"
+)
+
+func TestBytesMax(t *testing.T) {
+	tests := []struct {
+		config map[string]string
+		n      string
+		want   int
+	}{
+		{nil, "1024", 1024},
+		{nil, "200000", defaultMaxBytes},
+		{map[string]string{"bytes_max": "100"}, "1024", 100},
+		{map[string]string{"bytes_max": "100"}, "50", 50},
+		{map[string]string{"bytes_max": "-5"}, "200000", defaultMaxBytes},
+	}
+	for _, tt := range tests {
+		fakeConfig(t, tt.config)
+		rec := serve(t, "GET", "/bytes/"+tt.n, nil)
+		if rec.body.Len() != tt.want {
+			t.Errorf("bytes_max %q, /bytes/%s: %d bytes, want %d", tt.config["bytes_max"], tt.n, rec.body.Len(), tt.want)
+		}
+	}
+}
+
+func TestBytesSeedETag(t *testing.T) {
+	first := serve(t, "GET", "/bytes/64?seed=7", nil)
+	second := serve(t, "GET", "/bytes/64?seed=7", nil)
+	if !bytes.Equal(first.body.Bytes(), second.body.Bytes()) {
+		t.Error("seed 7 produced different bytes")
+	}
+	etag := first.header.Get("ETag")
+	if etag != `"bytes-64-7"` {
+		t.Errorf("ETag %q, want \"bytes-64-7\"", etag)
+	}
+	if got := serve(t, "GET", "/bytes/64?seed=7&format=hex", nil).header.Get("ETag"); got == etag {
+		t.Error("hex output shares the raw ETag")
+	}
+
+	rec := serve(t, "GET", "/bytes/64?seed=7", nil, "If-None-Match", etag)
+	if rec.status != 304 || rec.body.Len() != 0 {
+		t.Errorf("If-None-Match: status %d, %d bytes, want 304 and no body", rec.status, rec.body.Len())
+	}
+	if rec := serve(t, "GET", "/bytes/64", nil); rec.header.Get("ETag") != "" {
+		t.Errorf("unseeded: ETag %q, want none", rec.header.Get("ETag"))
+	}
+}
+
+func TestBytesFormat(t *testing.T) {
+	decoders := map[string]func(string) ([]byte, error){
+		"hex":    hex.DecodeString,
+		"base64": base64.StdEncoding.DecodeString,
+	}
+	for format, decode := range decoders {
+		for _, n := range []string{"1", "100", "1024"} {
+			rec := serve(t, "GET", "/bytes/"+n+"?format="+format, nil)
+			if got := rec.header.Get("Content-Type"); got != "text/plain; charset=utf-8" {
+				t.Errorf("%s %s: Content-Type %q", format, n, got)
+			}
+			data, err := decode(rec.body.String())
+			if err != nil {
+				t.Errorf("%s %s: %v", format, n, err)
+				continue
+			}
+			if strconv.Itoa(len(data)) != n {
+				t.Errorf("%s %s: decodes to %d bytes", format, n, len(data))
+			}
+		}
+	}
+	if rec := serve(t, "GET", "/bytes/8?format=base32", nil); rec.status != 400 {
+		t.Errorf("format=base32: status %d, want 400", rec.status)
+	}
+}
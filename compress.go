@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// responseEncodings are the content codings responses can be compressed
+// with, in order of preference.
+var responseEncodings = []string{"gzip", "deflate"}
+
+// negotiateEncoding picks the response coding preferred by an
+// Accept-Encoding header, or "" if the body should be sent as is.
+func negotiateEncoding(accept string) string {
+	best, bestQ := "", 0.0
+	for _, entry := range strings.Split(accept, ",") {
+		fields := strings.Split(entry, ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if coding == "x-gzip" {
+			coding = "gzip"
+		}
+		for _, enc := range responseEncodings {
+			if (coding == enc || coding == "*") && q > bestQ {
+				best, bestQ = enc, q
+				break
+			}
+		}
+	}
+	return best
+}
+
+// compress encodes data with the given content coding.
+func compress(coding string, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var zw io.WriteCloser
+	switch coding {
+	case "gzip":
+		zw = gzip.NewWriter(&buf)
+	case "deflate":
+		zw = zlib.NewWriter(&buf)
+	default:
+		return data, nil
+	}
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
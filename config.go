@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/fastly/compute-sdk-go/configstore"
+)
+
+// configStoreName is the Fastly config store operators can use to tune a
+// deployment. Every key is optional and falls back to a built-in default.
+const configStoreName = "edgehttpbin"
+
+var (
+	configOnce  sync.Once
+	configStore *configstore.Store
+)
+
+// configLookup reads key from the config store. It's a variable so the
+// store can be replaced outside of Compute@Edge.
+var configLookup = func(key string) (string, error) {
+	configOnce.Do(func() {
+		configStore, _ = configstore.Open(configStoreName)
+	})
+	return configStore.Get(key)
+}
+
+// configValue returns the value of key from the config store, if the store
+// exists and has it set.
+func configValue(key string) (string, bool) {
+	v, err := configLookup(key)
+	if err != nil || v == "" {
+		return "", false
+	}
+	return v, true
+}
+
+// configInt returns key from the config store as a positive integer, or def
+// if it is unset or invalid.
+func configInt(key string, def int) int {
+	v, ok := configValue(key)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
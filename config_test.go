@@ -0,0 +1,25 @@
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+// fakeConfig replaces the config store with values for the rest of the
+// test. The limits read from it once are forgotten so they're read again.
+func fakeConfig(t *testing.T, values map[string]string) {
+	saved := configLookup
+	t.Cleanup(func() {
+		configLookup = saved
+		resetConfigLimits()
+	})
+	configLookup = func(key string) (string, error) { return values[key], nil }
+	resetConfigLimits()
+}
+
+func resetConfigLimits() {
+	maxBytesOnce = sync.Once{}
+	maxBodyOnce = sync.Once{}
+	maxDelayOnce = sync.Once{}
+	maxRedirectsOnce = sync.Once{}
+}
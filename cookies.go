@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+func handleCookies(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	cookies := map[string]string{}
+	for _, c := range r.Cookies() {
+		value, err := url.QueryUnescape(c.Value)
+		if err != nil {
+			value = c.Value
+		}
+		cookies[c.Name] = value
+	}
+
+	writeJSON(w, r, fsthttp.StatusOK, map[string]map[string]string{"cookies": cookies})
+}
+
+func handleSetCookies(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	switch len(parts) {
+	case 3:
+		for name, values := range r.URL.Query() {
+			for _, value := range values {
+				setCookie(w, name, value, time.Time{})
+			}
+		}
+	case 5:
+		setCookie(w, parts[3], parts[4], time.Time{})
+	default:
+		fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Location", "/cookies")
+	fsthttp.Error(w, fsthttp.StatusText(302), 302)
+}
+
+func handleDeleteCookies(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	for name := range r.URL.Query() {
+		setCookie(w, name, "", time.Unix(0, 0))
+	}
+
+	w.Header().Set("Location", "/cookies")
+	fsthttp.Error(w, fsthttp.StatusText(302), 302)
+}
+
+// setCookie adds a Set-Cookie header for name. Each call adds its own
+// header, so setting several cookies never folds them into one line.
+func setCookie(w fsthttp.ResponseWriter, name, value string, expires time.Time) {
+	c := &fsthttp.Cookie{
+		Name:    name,
+		Value:   url.QueryEscape(value),
+		Path:    "/",
+		Expires: expires,
+	}
+	if !expires.IsZero() {
+		c.MaxAge = -1
+	}
+	fsthttp.SetCookie(w.Header(), c)
+}
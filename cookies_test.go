@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCookies(t *testing.T) {
+	rec := serve(t, "GET", "/cookies", nil, "Cookie", "a=1; b=2; c=hello%20world")
+	var body struct {
+		Cookies map[string]string `json:"cookies"`
+	}
+	if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"a": "1", "b": "2", "c": "hello world"}
+	if !reflect.DeepEqual(body.Cookies, want) {
+		t.Errorf("cookies = %v, want %v", body.Cookies, want)
+	}
+}
+
+func TestSetCookies(t *testing.T) {
+	tests := []struct {
+		target string
+		want   []string
+	}{
+		{"/cookies/set?a=1&a=2", []string{"a=1; Path=/", "a=2; Path=/"}},
+		{"/cookies/set/name/hello%20world", []string{"name=hello+world; Path=/"}},
+		{"/cookies/delete?a", []string{"a=; Path=/; Expires=Thu, 01 Jan 1970 00:00:00 GMT; Max-Age=0"}},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", tt.target, nil)
+		if rec.status != 302 || rec.header.Get("Location") != "/cookies" {
+			t.Errorf("GET %s: status %d, Location %q, want a 302 to /cookies", tt.target, rec.status, rec.header.Get("Location"))
+		}
+		if got := rec.header.Values("Set-Cookie"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GET %s: Set-Cookie = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+const allMethods = "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS"
+
+// corsOrigin returns the Access-Control-Allow-Origin value for the request,
+// or false if its origin isn't allowed. Without a cors_allowed_origins list
+// in the config store every origin is allowed. credentials is only set for
+// an origin the list names, so no other site can read responses made with
+// the user's cookies or credentials, such as those of /cookies.
+func corsOrigin(r *fsthttp.Request) (allowOrigin string, credentials, ok bool) {
+	origin := r.Header.Get("Origin")
+
+	allowed, ok := configValue("cors_allowed_origins")
+	if !ok {
+		return "*", false, true
+	}
+
+	wildcard := false
+	for _, o := range strings.Split(allowed, ",") {
+		o = strings.TrimSpace(o)
+		if origin != "" && o == origin {
+			return origin, true, true
+		}
+		wildcard = wildcard || o == "*"
+	}
+	if wildcard {
+		return "*", false, true
+	}
+	return "", false, false
+}
+
+// setCORSHeaders adds the CORS headers every response carries.
+func setCORSHeaders(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	origin, credentials, ok := corsOrigin(r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if credentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Add("Vary", "Origin")
+	}
+}
+
+// handlePreflight answers an OPTIONS request for a route allowing methods.
+func handlePreflight(w fsthttp.ResponseWriter, r *fsthttp.Request, methods []string) {
+	allow := allMethods
+	if methods != nil {
+		allow = strings.Join(append(append([]string{}, methods...), "OPTIONS"), ", ")
+	}
+	w.Header().Set("Allow", allow)
+
+	if _, _, ok := corsOrigin(r); ok && r.Header.Get("Access-Control-Request-Method") != "" {
+		w.Header().Set("Access-Control-Allow-Methods", allow)
+		if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+		}
+		w.Header().Set("Access-Control-Max-Age", "3600")
+	}
+	w.WriteHeader(fsthttp.StatusNoContent)
+}
+
+// handleCORS reports how the CORS policy treats the request's origin, so
+// browser harnesses can check the policy without a preflight.
+func handleCORS(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	origin, credentials, ok := corsOrigin(r)
+	writeJSON(w, r, fsthttp.StatusOK, map[string]interface{}{
+		"origin":            r.Header.Get("Origin"),
+		"allowed":           ok,
+		"allow_origin":      origin,
+		"allow_credentials": credentials,
+	})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestPreflight(t *testing.T) {
+	rec := serve(t, "OPTIONS", "/bytes/10", nil,
+		"Origin", "https://app.example",
+		"Access-Control-Request-Method", "GET",
+		"Access-Control-Request-Headers", "X-Test")
+	if rec.status != 204 {
+		t.Errorf("status %d, want 204", rec.status)
+	}
+	want := map[string]string{
+		"Access-Control-Allow-Origin":  "*",
+		"Access-Control-Allow-Methods": "GET, HEAD, OPTIONS",
+		"Access-Control-Allow-Headers": "X-Test",
+		"Access-Control-Max-Age":       "3600",
+		"Allow":                        "GET, HEAD, OPTIONS",
+	}
+	for key, value := range want {
+		if got := rec.header.Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestCORSOrigin(t *testing.T) {
+	tests := []struct {
+		name, allowed, origin string
+		allowOrigin           string
+		credentials           bool
+	}{
+		{"no list", "", "https://app.example", "*", false},
+		{"no list or origin", "", "", "*", false},
+		{"listed", "https://app.example, https://other.example", "https://app.example", "https://app.example", true},
+		{"wildcard", "https://other.example,*", "https://app.example", "*", false},
+		{"not listed", "https://other.example", "https://app.example", "", false},
+	}
+	for _, tt := range tests {
+		fakeConfig(t, map[string]string{"cors_allowed_origins": tt.allowed})
+		var rec *responseRecorder
+		if tt.origin == "" {
+			rec = serve(t, "GET", "/get", nil)
+		} else {
+			rec = serve(t, "GET", "/get", nil, "Origin", tt.origin)
+		}
+		if got := rec.header.Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+			t.Errorf("%s: Access-Control-Allow-Origin %q, want %q", tt.name, got, tt.allowOrigin)
+		}
+		if got := rec.header.Get("Access-Control-Allow-Credentials") == "true"; got != tt.credentials {
+			t.Errorf("%s: credentials %v, want %v", tt.name, got, tt.credentials)
+		}
+	}
+}
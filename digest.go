@@ -0,0 +1,229 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// digestAlgorithms maps the algorithm names accepted in the path to the
+// name used in the challenge and the hash used to compute the response.
+var digestAlgorithms = map[string]struct {
+	name string
+	hash func() hash.Hash
+}{
+	"md5":     {"MD5", md5.New},
+	"sha-256": {"SHA-256", sha256.New},
+}
+
+func handleDigestAuth(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) != 5 && len(parts) != 6 {
+		fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
+		return
+	}
+
+	qop, user, passwd := parts[2], parts[3], parts[4]
+	if qop != "auth" && qop != "auth-int" {
+		fsthttp.Error(w, "Invalid qop, must be auth or auth-int", fsthttp.StatusBadRequest)
+		return
+	}
+
+	algoName := "md5"
+	if len(parts) == 6 {
+		algoName = strings.ToLower(parts[5])
+	}
+	algo, ok := digestAlgorithms[algoName]
+	if !ok {
+		fsthttp.Error(w, "Invalid algorithm, must be MD5 or SHA-256", fsthttp.StatusBadRequest)
+		return
+	}
+
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Digest ") {
+		digestChallenge(w, qop, algo.name, false)
+		return
+	}
+
+	params := parseDigestParams(strings.TrimPrefix(auth, "Digest "))
+	issued, ok := digestNonceIssued(params["nonce"])
+	if !ok ||
+		params["username"] != user ||
+		params["realm"] != digestRealm ||
+		params["qop"] != qop ||
+		params["uri"] != clientRequestURI(r) ||
+		!digestNCRx.MatchString(params["nc"]) ||
+		params["cnonce"] == "" ||
+		!hmac.Equal([]byte(params["opaque"]), []byte(digestOpaque(params["nonce"]))) {
+		digestChallenge(w, qop, algo.name, false)
+		return
+	}
+	if a := params["algorithm"]; a != "" && !strings.EqualFold(a, algo.name) {
+		digestChallenge(w, qop, algo.name, false)
+		return
+	}
+
+	var body []byte
+	if qop == "auth-int" {
+		var err error
+		if body, err = readBody(r); err != nil {
+			bodyError(w, err)
+			return
+		}
+	}
+	expected := digestResponse(algo.hash, user, digestRealm, passwd, r.Method, params, body)
+	if !hmac.Equal([]byte(params["response"]), []byte(expected)) {
+		digestChallenge(w, qop, algo.name, false)
+		return
+	}
+	// The credentials are right but the nonce has expired, so the client
+	// can retry with a new nonce without asking the user again.
+	if time.Since(issued) > digestNonceTTL {
+		digestChallenge(w, qop, algo.name, true)
+		return
+	}
+
+	writeJSON(w, r, fsthttp.StatusOK, map[string]interface{}{"authenticated": true, "user": user})
+}
+
+const (
+	digestRealm = "edgehttpbin"
+
+	// digestNonceTTL is how long a nonce is accepted for before the client
+	// is told it's stale.
+	digestNonceTTL = 5 * time.Minute
+
+	// digestTestKey signs nonces when the secret store has no
+	// digest_nonce_key. It's public, so such nonces can be forged by anyone
+	// who reads this.
+	digestTestKey = "edgehttpbin-digest-test-key"
+)
+
+// digestNCRx matches the nc parameter: the request count as 8 hex digits.
+var digestNCRx = regexp.MustCompile(`^[0-9a-fA-F]{8}$`)
+
+// digestKey returns the HMAC key nonces and opaque values are signed with.
+func digestKey() []byte {
+	if key, ok := secretValue("digest_nonce_key"); ok {
+		return key
+	}
+	return []byte(digestTestKey)
+}
+
+// digestMAC returns the hex HMAC-SHA256 of input under digestKey.
+func digestMAC(input string) string {
+	mac := hmac.New(sha256.New, digestKey())
+	mac.Write([]byte(input))
+	return fmt.Sprintf("%x", mac.Sum(nil))
+}
+
+// digestChallenge issues a fresh nonce. The nonce is the time it was issued
+// at and some random bytes, in hex, followed by an HMAC of the two, so its
+// age can be checked on the follow-up request without keeping any state
+// between executions. The opaque value is an HMAC of the nonce.
+func digestChallenge(w fsthttp.ResponseWriter, qop, algo string, stale bool) {
+	b := make([]byte, 16)
+	rand.Read(b)
+	nonce := fmt.Sprintf("%016x%x", time.Now().Unix(), b)
+	nonce += digestMAC(nonce)
+
+	challenge := fmt.Sprintf(
+		`Digest realm="%s", qop="%s", nonce="%s", opaque="%s", algorithm=%s`,
+		digestRealm, qop, nonce, digestOpaque(nonce), algo,
+	)
+	if stale {
+		challenge += ", stale=true"
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+// digestNonceIssued returns the time nonce was issued at, checking it was
+// issued by digestChallenge. A nonce from the future can only be forged, so
+// it's refused too.
+func digestNonceIssued(nonce string) (time.Time, bool) {
+	// The time and random bytes, then the HMAC, all in hex.
+	const signedLen = 16 + 32
+	if len(nonce) != signedLen+64 ||
+		!hmac.Equal([]byte(nonce[signedLen:]), []byte(digestMAC(nonce[:signedLen]))) {
+		return time.Time{}, false
+	}
+	secs, err := strconv.ParseInt(nonce[:16], 16, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	issued := time.Unix(secs, 0)
+	if issued.After(time.Now()) {
+		return time.Time{}, false
+	}
+	return issued, true
+}
+
+func digestOpaque(nonce string) string {
+	return digestMAC("opaque:" + nonce)
+}
+
+// digestResponse computes the expected response value as described in RFC
+// 7616 section 3.4.1. The body is only part of it for qop=auth-int.
+func digestResponse(h func() hash.Hash, user, realm, passwd, method string, params map[string]string, body []byte) string {
+	ha1 := hexHash(h, user+":"+realm+":"+passwd)
+	a2 := method + ":" + params["uri"]
+	if params["qop"] == "auth-int" {
+		a2 += ":" + hexHash(h, string(body))
+	}
+	ha2 := hexHash(h, a2)
+	return hexHash(h, strings.Join([]string{
+		ha1, params["nonce"], params["nc"], params["cnonce"], params["qop"], ha2,
+	}, ":"))
+}
+
+// hexHash returns the hex encoded digest of input using h.
+func hexHash(h func() hash.Hash, input string) string {
+	hh := h()
+	hh.Write([]byte(input))
+	return fmt.Sprintf("%x", hh.Sum(nil))
+}
+
+// parseDigestParams splits the comma separated key=value pairs of a Digest
+// Authorization header, unquoting values where needed.
+func parseDigestParams(input string) map[string]string {
+	params := map[string]string{}
+	for len(input) > 0 {
+		input = strings.TrimLeft(input, " ,")
+		eq := strings.IndexByte(input, '=')
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(input[:eq]))
+		input = input[eq+1:]
+
+		var value string
+		if strings.HasPrefix(input, `"`) {
+			end := strings.IndexByte(input[1:], '"')
+			if end < 0 {
+				break
+			}
+			value = input[1 : end+1]
+			input = input[end+2:]
+		} else {
+			end := strings.IndexByte(input, ',')
+			if end < 0 {
+				end = len(input)
+			}
+			value = strings.TrimSpace(input[:end])
+			input = input[end:]
+		}
+		params[key] = value
+	}
+	return params
+}
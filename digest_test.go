@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDigestResponseRFC7616(t *testing.T) {
+	// The example of RFC 7616 section 3.9.1.
+	params := map[string]string{
+		"uri":    "/dir/index.html",
+		"nonce":  "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+		"nc":     "00000001",
+		"cnonce": "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ",
+		"qop":    "auth",
+	}
+	tests := []struct {
+		hash func() hash.Hash
+		want string
+	}{
+		{md5.New, "8ca523f5e9506fed4657c9700eebdbec"},
+		{sha256.New, "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1"},
+	}
+	for _, tt := range tests {
+		got := digestResponse(tt.hash, "Mufasa", "http-auth@example.org", "Circle of Life", "GET", params, nil)
+		if got != tt.want {
+			t.Errorf("digestResponse = %s, want %s", got, tt.want)
+		}
+	}
+}
+
+// digestAuthorization answers the challenge of a 401 from /digest-auth.
+func digestAuthorization(t *testing.T, challenge, uri, passwd, nc string) string {
+	t.Helper()
+	return digestAuthorizationFor(t, challenge, "GET", uri, passwd, nc, nil)
+}
+
+// digestAuthorizationFor answers the challenge for a request with the given
+// method and body, the body only counting for qop=auth-int.
+func digestAuthorizationFor(t *testing.T, challenge, method, uri, passwd, nc string, body []byte) string {
+	t.Helper()
+	params := parseDigestParams(strings.TrimPrefix(challenge, "Digest "))
+	params["uri"] = uri
+	params["nc"] = nc
+	params["cnonce"] = "0a4f113b"
+	h := md5.New
+	if params["algorithm"] == "SHA-256" {
+		h = sha256.New
+	}
+	response := digestResponse(h, "user", params["realm"], passwd, method, params, body)
+	return fmt.Sprintf(
+		`Digest username="user", realm="%s", nonce="%s", uri="%s", algorithm=%s, qop=%s, nc=%s, cnonce="%s", response="%s", opaque="%s"`,
+		params["realm"], params["nonce"], uri, params["algorithm"], params["qop"], nc, params["cnonce"], response, params["opaque"],
+	)
+}
+
+func TestDigestAuth(t *testing.T) {
+	for _, uri := range []string{"/digest-auth/auth/user/passwd", "/digest-auth/auth/user/passwd/SHA-256"} {
+		rec := serve(t, "GET", uri, nil)
+		if rec.status != 401 {
+			t.Fatalf("GET %s without credentials: status %d, want 401", uri, rec.status)
+		}
+		challenge := rec.header.Get("WWW-Authenticate")
+
+		rec = serve(t, "GET", uri, nil, "Authorization", digestAuthorization(t, challenge, uri, "passwd", "00000001"))
+		if rec.status != 200 {
+			t.Errorf("GET %s: status %d, want 200", uri, rec.status)
+		}
+	}
+}
+
+func TestDigestAuthRejects(t *testing.T) {
+	const uri = "/digest-auth/auth/user/passwd"
+	challenge := serve(t, "GET", uri, nil).header.Get("WWW-Authenticate")
+	nonce := parseDigestParams(strings.TrimPrefix(challenge, "Digest "))["nonce"]
+
+	// A nonce claiming to be from an hour ahead, with the rest unchanged.
+	future := fmt.Sprintf("%016x", time.Now().Add(time.Hour).Unix()) + nonce[16:]
+
+	tests := []struct {
+		name, auth string
+	}{
+		{"wrong password", digestAuthorization(t, challenge, uri, "wrong", "00000001")},
+		{"other uri", digestAuthorization(t, challenge, "/digest-auth/auth/user/other", "passwd", "00000001")},
+		{"bad nc", digestAuthorization(t, challenge, uri, "passwd", "1")},
+		{"forged nonce", digestAuthorization(t, strings.Replace(challenge, nonce, future, 1), uri, "passwd", "00000001")},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", uri, nil, "Authorization", tt.auth)
+		if rec.status != 401 {
+			t.Errorf("%s: status %d, want 401", tt.name, rec.status)
+		}
+		if strings.Contains(rec.header.Get("WWW-Authenticate"), "stale=true") {
+			t.Errorf("%s: challenge is marked stale", tt.name)
+		}
+	}
+}
+
+func TestDigestNonceIssued(t *testing.T) {
+	unsigned := fmt.Sprintf("%016x%032x", time.Now().Unix(), 0)
+	if _, ok := digestNonceIssued(unsigned + digestMAC(unsigned)); !ok {
+		t.Error("signed nonce refused")
+	}
+	if _, ok := digestNonceIssued(unsigned + strings.Repeat("0", 64)); ok {
+		t.Error("nonce with a bad signature accepted")
+	}
+
+	future := fmt.Sprintf("%016x%032x", time.Now().Add(time.Hour).Unix(), 0)
+	if _, ok := digestNonceIssued(future + digestMAC(future)); ok {
+		t.Error("nonce from the future accepted")
+	}
+
+	old := fmt.Sprintf("%016x%032x", time.Now().Add(-time.Hour).Unix(), 0)
+	issued, ok := digestNonceIssued(old + digestMAC(old))
+	if !ok || time.Since(issued) <= digestNonceTTL {
+		t.Errorf("old nonce: issued %v, ok %v", issued, ok)
+	}
+}
+
+func TestDigestAuthTrailingSlash(t *testing.T) {
+	// The client hashes the URI it sent, slash and all, even though the
+	// route is found without it.
+	const uri = "/digest-auth/auth/user/passwd/"
+	challenge := serve(t, "GET", uri, nil).header.Get("WWW-Authenticate")
+	rec := serve(t, "GET", uri, nil, "Authorization", digestAuthorization(t, challenge, uri, "passwd", "00000001"))
+	if rec.status != 200 {
+		t.Errorf("GET %s: status %d, want 200", uri, rec.status)
+	}
+}
+
+func TestDigestAuthInt(t *testing.T) {
+	const uri = "/digest-auth/auth-int/user/passwd"
+	body := []byte(`{"hello":"world"}`)
+	challenge := serve(t, "POST", uri, nil).header.Get("WWW-Authenticate")
+	if !strings.Contains(challenge, `qop="auth-int"`) {
+		t.Fatalf("challenge %q doesn't offer auth-int", challenge)
+	}
+
+	auth := digestAuthorizationFor(t, challenge, "POST", uri, "passwd", "00000001", body)
+	if rec := serve(t, "POST", uri, bytes.NewReader(body), "Authorization", auth); rec.status != 200 {
+		t.Errorf("matching body: status %d, want 200", rec.status)
+	}
+	if rec := serve(t, "POST", uri, strings.NewReader(`{"hello":"there"}`), "Authorization", auth); rec.status != 401 {
+		t.Errorf("different body: status %d, want 401", rec.status)
+	}
+}
+
+func TestDigestAuthStale(t *testing.T) {
+	const uri = "/digest-auth/auth/user/passwd"
+	challenge := serve(t, "GET", uri, nil).header.Get("WWW-Authenticate")
+	params := parseDigestParams(strings.TrimPrefix(challenge, "Digest "))
+
+	// A correctly signed nonce issued longer ago than it stays valid.
+	expired := fmt.Sprintf("%016x", time.Now().Add(-2*digestNonceTTL).Unix()) + params["nonce"][16:48]
+	expired += digestMAC(expired)
+	challenge = strings.Replace(challenge, params["nonce"], expired, 1)
+	challenge = strings.Replace(challenge, params["opaque"], digestOpaque(expired), 1)
+
+	rec := serve(t, "GET", uri, nil, "Authorization", digestAuthorization(t, challenge, uri, "passwd", "00000001"))
+	if rec.status != 401 {
+		t.Errorf("status %d, want 401", rec.status)
+	}
+	if !strings.Contains(rec.header.Get("WWW-Authenticate"), "stale=true") {
+		t.Errorf("WWW-Authenticate %q, want stale=true", rec.header.Get("WWW-Authenticate"))
+	}
+
+	// With the wrong password the client has to ask again, so it isn't stale.
+	rec = serve(t, "GET", uri, nil, "Authorization", digestAuthorization(t, challenge, uri, "wrong", "00000001"))
+	if rec.status != 401 || strings.Contains(rec.header.Get("WWW-Authenticate"), "stale=true") {
+		t.Errorf("wrong password: status %d, WWW-Authenticate %q", rec.status, rec.header.Get("WWW-Authenticate"))
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+const (
+	defaultDripBytes    = 10
+	defaultDripDuration = 2 * time.Second
+	defaultDripDelay    = 2 * time.Second
+
+	// maxDripWrites bounds how many writes a drip is split into, so a long
+	// drip of many bytes sends them a few at a time rather than one by one.
+	maxDripWrites = 1000
+)
+
+// handleDrip waits ?delay=, then sends ?numbytes= asterisks spread evenly
+// over ?duration=, with ?code= as the status. The delay and duration
+// together can't exceed the delay cap.
+func handleDrip(ctx context.Context, w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	query := r.URL.Query()
+
+	numBytes := defaultDripBytes
+	if param := query.Get("numbytes"); param != "" {
+		n, err := strconv.Atoi(param)
+		if err != nil || n < 0 || n > maxBytes() {
+			fsthttp.Error(w, fmt.Sprintf("Invalid numbytes, must be between 0 and %d", maxBytes()), fsthttp.StatusBadRequest)
+			return
+		}
+		numBytes = n
+	}
+
+	duration, delay := defaultDripDuration, defaultDripDelay
+	var err error
+	if param := query.Get("duration"); param != "" {
+		if duration, err = parseBoundedDuration(param, 0, maxDelay()); err != nil {
+			fsthttp.Error(w, "Invalid duration", fsthttp.StatusBadRequest)
+			return
+		}
+	}
+	if param := query.Get("delay"); param != "" {
+		if delay, err = parseBoundedDuration(param, 0, maxDelay()); err != nil {
+			fsthttp.Error(w, "Invalid delay", fsthttp.StatusBadRequest)
+			return
+		}
+	}
+	if duration+delay > maxDelay() {
+		fsthttp.Error(w, fmt.Sprintf("delay and duration together can't exceed %s", maxDelay()), fsthttp.StatusBadRequest)
+		return
+	}
+
+	code := fsthttp.StatusOK
+	if param := query.Get("code"); param != "" {
+		code, err = strconv.Atoi(param)
+		if err != nil || code < 200 || code > 599 {
+			fsthttp.Error(w, "Invalid code", fsthttp.StatusBadRequest)
+			return
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		w.WriteHeader(499)
+		return
+	case <-time.After(delay):
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(numBytes))
+	w.Header().Set("Cache-Control", "no-store, max-age=0")
+	w.WriteHeader(code)
+	if numBytes == 0 {
+		return
+	}
+
+	writes := numBytes
+	if writes > maxDripWrites {
+		writes = maxDripWrites
+	}
+	// As in httpbin, each write is followed by a pause, so the response
+	// finishes once duration has passed. The pauses are timed from the
+	// start, so the time spent writing doesn't add up over a long drip.
+	start, interval := time.Now(), duration/time.Duration(writes)
+	sent := 0
+	for i := 0; i < writes; i++ {
+		// Spread any remainder over the writes rather than leaving it
+		// all for the last one.
+		next := numBytes * (i + 1) / writes
+		w.Write(bytes.Repeat([]byte("*"), next-sent))
+		sent = next
+
+		if wait := time.Until(start.Add(time.Duration(i+1) * interval)); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDrip(t *testing.T) {
+	start := time.Now()
+	rec := serve(t, "GET", "/drip?numbytes=5&duration=0.2&delay=0&code=201", nil)
+	elapsed := time.Since(start)
+	if rec.status != 201 || rec.body.String() != "*****" {
+		t.Errorf("status %d, body %q", rec.status, rec.body.String())
+	}
+	if elapsed < 200*time.Millisecond || elapsed > time.Second {
+		t.Errorf("finished after %v, want the 200ms duration", elapsed)
+	}
+}
+
+func TestDripInvalid(t *testing.T) {
+	for _, query := range []string{"code=199", "code=600", "code=999", "numbytes=-1", "duration=-1", "delay=NaN"} {
+		if rec := serve(t, "GET", "/drip?"+query, nil); rec.status != 400 {
+			t.Errorf("%s: status %d, want 400", query, rec.status)
+		}
+	}
+}
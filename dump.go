@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+const defaultDumpBodyMax = 10 * 1024
+
+// handleDump writes the request back in an approximation of its HTTP/1.x
+// wire format. The SDK doesn't expose the original header order, so headers
+// are sorted by name.
+func handleDump(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	var b strings.Builder
+
+	proto := r.Proto
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	fmt.Fprintf(&b, "%s %s %s\r\n", r.Method, r.URL.RequestURI(), proto)
+
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+	fmt.Fprintf(&b, "Host: %s\r\n", host)
+
+	keys := r.Header.Keys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key == "Host" {
+			continue
+		}
+		for _, value := range r.Header.Values(key) {
+			fmt.Fprintf(&b, "%s: %s\r\n", key, value)
+		}
+	}
+	b.WriteString("\r\n")
+
+	limit := int64(configInt("dump_body_max", defaultDumpBodyMax))
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		fsthttp.Error(w, err.Error(), fsthttp.StatusBadRequest)
+		return
+	}
+	truncated := int64(len(body)) > limit
+	if truncated {
+		body = body[:limit]
+	}
+	b.Write(body)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if truncated {
+		w.Header().Set("X-Dump-Truncated", "true")
+	}
+	w.Write([]byte(b.String()))
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	rec := serve(t, "POST", "http://edgehttpbin.test/dump?a=1", strings.NewReader("hello"), "X-Test", "value")
+	got := rec.body.String()
+	for _, want := range []string{"POST /dump?a=1 HTTP/1.1\r\n", "Host: edgehttpbin.test\r\n", "X-Test: value\r\n", "\r\n\r\nhello"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("dump doesn't contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestDumpBodyMax(t *testing.T) {
+	fakeConfig(t, map[string]string{"dump_body_max": "4"})
+	rec := serve(t, "POST", "/dump", strings.NewReader("hello"))
+	if !strings.HasSuffix(rec.body.String(), "\r\n\r\nhell") || rec.header.Get("X-Dump-Truncated") != "true" {
+		t.Errorf("X-Dump-Truncated %q, body %q", rec.header.Get("X-Dump-Truncated"), rec.body.String())
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// echoInfo is the httpbin description of a request with a body, returned by
+// the method endpoints such as /post. Method is only set by /anything, which
+// takes every method.
+type echoInfo struct {
+	requestInfo
+	Method string                 `json:"method,omitempty"`
+	Data   string                 `json:"data"`
+	Form   map[string]interface{} `json:"form"`
+	Files  map[string]interface{} `json:"files"`
+	JSON   interface{}            `json:"json"`
+}
+
+// newEchoInfo reads the request body and describes it as httpbin does. Form
+// bodies are parsed into form, and files for multipart bodies, rather than
+// repeated in data. JSON bodies are decoded into json, and bodies that aren't
+// UTF-8 are sent back as a base64 data URL.
+func newEchoInfo(r *fsthttp.Request) (echoInfo, error) {
+	info := echoInfo{
+		requestInfo: newRequestInfo(r),
+		Form:        map[string]interface{}{},
+		Files:       map[string]interface{}{},
+	}
+	body, err := readBody(r)
+	if err != nil {
+		return info, err
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "multipart/form-data":
+		form, files, err := parseMultipartForm(body, params["boundary"])
+		if err != nil {
+			return info, err
+		}
+		info.Form, info.Files = flattenValues(form), flattenValues(files)
+		return info, nil
+	case "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return info, err
+		}
+		info.Form = flattenValues(form)
+		return info, nil
+	}
+	if isJSONMediaType(mediaType) {
+		// A body that doesn't parse leaves json null, with the body still
+		// in data.
+		info.JSON, _ = decodeJSONBody(body)
+	}
+
+	info.Data = bodyText(body)
+	return info, nil
+}
+
+// isJSONMediaType reports whether mediaType is application/json or uses the
+// +json structured syntax suffix, as application/merge-patch+json does.
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" ||
+		(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
+
+// decodeJSONBody decodes a single JSON value, keeping numbers as they were
+// written so large integers come back exactly rather than rounded through a
+// float64.
+func decodeJSONBody(body []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return v, nil
+}
+
+// bodyText returns data as a string when it's UTF-8, and as a base64 data URL
+// otherwise, so binary content survives the trip through JSON.
+func bodyText(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	return "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// parseMultipartForm splits a multipart/form-data body into its form values
+// and its files, keyed by field name. File contents are given as bodyText.
+func parseMultipartForm(body []byte, boundary string) (form, files url.Values, err error) {
+	if boundary == "" {
+		return nil, nil, errors.New("multipart body has no boundary")
+	}
+	form, files = url.Values{}, url.Values{}
+	mr := multipart.NewReader(bytes.NewReader(body), boundary)
+	for n := 0; ; n++ {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return form, files, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if n == maxMultipartParts {
+			return nil, nil, fmt.Errorf("too many parts, at most %d are allowed", maxMultipartParts)
+		}
+		data, err := io.ReadAll(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		if p.FileName() != "" {
+			files.Add(p.FormName(), bodyText(data))
+		} else {
+			form.Add(p.FormName(), string(data))
+		}
+	}
+}
+
+// handleEcho returns the request, including its body, for /delete, /patch,
+// /post and /put. The route table limits each to its own method.
+func handleEcho(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	info, err := newEchoInfo(r)
+	if err != nil {
+		bodyError(w, err)
+		return
+	}
+	writeJSON(w, r, fsthttp.StatusOK, info)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEchoMethods(t *testing.T) {
+	tests := []struct {
+		method, target string
+	}{
+		{"PUT", "/put"},
+		{"PATCH", "/patch"},
+		{"DELETE", "/delete"},
+	}
+	for _, tt := range tests {
+		rec := serve(t, tt.method, tt.target+"?a=1", strings.NewReader(`{"k":"v"}`), "Content-Type", "application/json")
+		if rec.status != 200 {
+			t.Fatalf("%s %s: status %d, want 200", tt.method, tt.target, rec.status)
+		}
+		var body echoInfo
+		if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Data != `{"k":"v"}` || !reflect.DeepEqual(body.JSON, map[string]interface{}{"k": "v"}) {
+			t.Errorf("%s %s: data %q, json %v", tt.method, tt.target, body.Data, body.JSON)
+		}
+		if body.Args["a"] != "1" {
+			t.Errorf("%s %s: args %v", tt.method, tt.target, body.Args)
+		}
+
+		rec = serve(t, "GET", tt.target, nil)
+		if rec.status != 405 || rec.header.Get("Allow") != tt.method {
+			t.Errorf("GET %s: status %d, Allow %q, want 405 and %s", tt.target, rec.status, rec.header.Get("Allow"), tt.method)
+		}
+	}
+}
+
+func TestEchoMultipart(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("greeting", "hello")
+	fw, _ := mw.CreateFormFile("upload", "notes.txt")
+	fw.Write([]byte("file contents"))
+	mw.Close()
+
+	for _, target := range []string{"/post", "/anything"} {
+		rec := serve(t, "POST", target, bytes.NewReader(body.Bytes()), "Content-Type", mw.FormDataContentType())
+		if rec.status != 200 {
+			t.Fatalf("POST %s: status %d", target, rec.status)
+		}
+		var got echoInfo
+		if err := json.Unmarshal(rec.body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.Form, map[string]interface{}{"greeting": "hello"}) {
+			t.Errorf("POST %s: form %v", target, got.Form)
+		}
+		if !reflect.DeepEqual(got.Files, map[string]interface{}{"upload": "file contents"}) {
+			t.Errorf("POST %s: files %v", target, got.Files)
+		}
+		if got.Data != "" {
+			t.Errorf("POST %s: data %q, want the body left out", target, got.Data)
+		}
+	}
+}
+
+func TestEchoJSON(t *testing.T) {
+	tests := []struct {
+		contentType, body string
+		want              interface{}
+	}{
+		{"application/json", `{"a":[1,2]}`, map[string]interface{}{"a": []interface{}{"1", "2"}}},
+		{"application/vnd.api+json", `{"data":{"type":"x"}}`, map[string]interface{}{"data": map[string]interface{}{"type": "x"}}},
+		{"application/json; charset=utf-8", `9007199254740993`, "9007199254740993"},
+		{"application/json", `{"a":1} trailing`, nil},
+		{"text/plain", `{"a":"b"}`, nil},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "POST", "/post", strings.NewReader(tt.body), "Content-Type", tt.contentType)
+		var got struct {
+			Data string          `json:"data"`
+			JSON json.RawMessage `json:"json"`
+		}
+		if err := json.Unmarshal(rec.body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		// Numbers are compared as written, so any rounding shows.
+		dec := json.NewDecoder(bytes.NewReader(got.JSON))
+		dec.UseNumber()
+		var v interface{}
+		dec.Decode(&v)
+		if !reflect.DeepEqual(stringifyNumbers(v), tt.want) {
+			t.Errorf("%s %s: json %s", tt.contentType, tt.body, got.JSON)
+		}
+		if got.Data != tt.body {
+			t.Errorf("%s %s: data %q", tt.contentType, tt.body, got.Data)
+		}
+	}
+
+	rec := serve(t, "POST", "/post", strings.NewReader(`{"id":9007199254740993}`), "Content-Type", "application/json")
+	if !strings.Contains(rec.body.String(), `"json":{"id":9007199254740993}`) {
+		t.Errorf("large integer was rounded: %s", rec.body.String())
+	}
+}
+
+// stringifyNumbers replaces each json.Number in v with its text.
+func stringifyNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		return v.String()
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = stringifyNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = stringifyNumbers(e)
+		}
+	}
+	return v
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// getenv reads the environment Compute@Edge populates with details of the
+// serving POP.
+var getenv = os.Getenv
+
+// edgeInfo describes where and how a request reached the edge. Fields the
+// platform doesn't provide are left out. The SDK has no view of the cache
+// status of the client request, so that isn't reported.
+type edgeInfo struct {
+	POP            string `json:"pop,omitempty"`
+	Region         string `json:"region,omitempty"`
+	Hostname       string `json:"hostname,omitempty"`
+	ServiceVersion string `json:"service_version,omitempty"`
+	TraceID        string `json:"trace_id,omitempty"`
+	Protocol       string `json:"protocol,omitempty"`
+	TLSProtocol    string `json:"tls_protocol,omitempty"`
+	TLSCipher      string `json:"tls_cipher,omitempty"`
+}
+
+func handleDebugEdge(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	w.Header().Set("Cache-Control", "no-store, max-age=0")
+	writeJSON(w, r, fsthttp.StatusOK, edgeInfo{
+		POP:            getenv("FASTLY_POP"),
+		Region:         getenv("FASTLY_REGION"),
+		Hostname:       getenv("FASTLY_HOSTNAME"),
+		ServiceVersion: getenv("FASTLY_SERVICE_VERSION"),
+		TraceID:        getenv("FASTLY_TRACE_ID"),
+		Protocol:       r.Proto,
+		TLSProtocol:    r.TLSInfo.Protocol,
+		TLSCipher:      r.TLSInfo.CipherOpenSSLName,
+	})
+}
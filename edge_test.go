@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+func TestDebugEdge(t *testing.T) {
+	env := map[string]string{
+		"FASTLY_POP":             "LHR",
+		"FASTLY_REGION":          "EU-West",
+		"FASTLY_SERVICE_VERSION": "7",
+	}
+	saved := getenv
+	getenv = func(key string) string { return env[key] }
+	t.Cleanup(func() { getenv = saved })
+
+	req, err := fsthttp.NewRequest("GET", "/debug/edge", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Proto = "HTTP/2"
+	req.TLSInfo.Protocol = "TLSv1.3"
+	req.TLSInfo.CipherOpenSSLName = "TLS_AES_128_GCM_SHA256"
+	rec := newResponseRecorder()
+	handler(context.Background(), rec, req)
+
+	if rec.status != 200 {
+		t.Fatalf("status %d, want 200", rec.status)
+	}
+	var got map[string]string
+	if err := json.Unmarshal(rec.body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"pop":             "LHR",
+		"region":          "EU-West",
+		"service_version": "7",
+		"protocol":        "HTTP/2",
+		"tls_protocol":    "TLSv1.3",
+		"tls_cipher":      "TLS_AES_128_GCM_SHA256",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
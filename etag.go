@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+func handleETag(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) != 3 || parts[2] == "" {
+		fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
+		return
+	}
+
+	if !validHeaderValue(parts[2]) {
+		fsthttp.Error(w, "Invalid etag, control characters aren't allowed", fsthttp.StatusBadRequest)
+		return
+	}
+	etag := `"` + parts[2] + `"`
+	w.Header().Set("ETag", etag)
+
+	if !checkConditional(w, r, etag) {
+		return
+	}
+
+	writeJSON(w, r, fsthttp.StatusOK, map[string]string{"etag": parts[2]})
+}
+
+// checkConditional evaluates If-Match and If-None-Match against etag as
+// described in RFC 7232 section 6. It writes a 412 or 304 and returns false
+// when the request shouldn't be served.
+func checkConditional(w fsthttp.ResponseWriter, r *fsthttp.Request, etag string) bool {
+	if header := r.Header.Get("If-Match"); header != "" {
+		if !etagListMatches(header, etag, strongETagMatch) {
+			fsthttp.Error(w, fsthttp.StatusText(412), fsthttp.StatusPreconditionFailed)
+			return false
+		}
+	}
+
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		if etagListMatches(header, etag, weakETagMatch) {
+			w.WriteHeader(fsthttp.StatusNotModified)
+			return false
+		}
+	}
+	return true
+}
+
+// etagListMatches reports whether any tag in the comma separated list
+// matches etag using cmp. A bare * matches any current representation.
+func etagListMatches(list, etag string, cmp func(a, b string) bool) bool {
+	for _, tag := range strings.Split(list, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || (tag != "" && cmp(tag, etag)) {
+			return true
+		}
+	}
+	return false
+}
+
+func strongETagMatch(a, b string) bool {
+	return !strings.HasPrefix(a, "W/") && !strings.HasPrefix(b, "W/") && a == b
+}
+
+func weakETagMatch(a, b string) bool {
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
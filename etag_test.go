@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestETag(t *testing.T) {
+	tests := []struct {
+		name, header, value string
+		status              int
+	}{
+		{"no conditions", "", "", 200},
+		{"If-None-Match matches", "If-None-Match", `"abc"`, 304},
+		{"If-None-Match matches in a list", "If-None-Match", `"x", W/"abc"`, 304},
+		{"If-None-Match star", "If-None-Match", "*", 304},
+		{"If-None-Match differs", "If-None-Match", `"x"`, 200},
+		{"If-Match matches", "If-Match", `"x", "abc"`, 200},
+		{"If-Match differs", "If-Match", `"x"`, 412},
+		{"If-Match weak", "If-Match", `W/"abc"`, 412},
+	}
+	for _, tt := range tests {
+		var rec *responseRecorder
+		if tt.header == "" {
+			rec = serve(t, "GET", "/etag/abc", nil)
+		} else {
+			rec = serve(t, "GET", "/etag/abc", nil, tt.header, tt.value)
+		}
+		if rec.status != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.status, tt.status)
+		}
+		if got := rec.header.Get("ETag"); got != `"abc"` {
+			t.Errorf("%s: ETag %q", tt.name, got)
+		}
+	}
+}
+
+func TestETagControlCharacters(t *testing.T) {
+	for _, target := range []string{"/etag/abc%0d%0aSet-Cookie:%20x=1", "/etag/abc%0a", "/etag/a%00b"} {
+		rec := serve(t, "GET", target, nil)
+		if rec.status != 400 || rec.header.Get("ETag") != "" || rec.header.Get("Set-Cookie") != "" {
+			t.Errorf("GET %s: status %d, ETag %q, want 400 and no headers", target, rec.status, rec.header.Get("ETag"))
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+	"github.com/fastly/compute-sdk-go/geo"
+)
+
+// geoLookup is a variable so the host call can be replaced outside of
+// Compute@Edge.
+var geoLookup = geo.Lookup
+
+type geoResponse struct {
+	IP        string   `json:"ip"`
+	Country   *string  `json:"country"`
+	City      *string  `json:"city"`
+	Region    *string  `json:"region"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	ASN       *int     `json:"asn"`
+	ASName    *string  `json:"as_name"`
+}
+
+func handleGeo(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	resp := geoResponse{IP: clientIP(r)}
+
+	// Missing geo data isn't an error for the client, so every field is
+	// left null if the lookup fails, and names it has no value for are too.
+	if ip := net.ParseIP(resp.IP); ip != nil {
+		if g, err := geoLookup(ip); err == nil {
+			resp.Country = nonEmpty(g.CountryCode)
+			resp.City = nonEmpty(g.City)
+			resp.Region = nonEmpty(g.Region)
+			resp.Latitude = &g.Latitude
+			resp.Longitude = &g.Longitude
+			resp.ASN = &g.AsNumber
+			resp.ASName = nonEmpty(g.AsName)
+		}
+	}
+
+	writeJSON(w, r, fsthttp.StatusOK, resp)
+}
+
+// nonEmpty returns a pointer to s, or nil if it's empty.
+func nonEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/fastly/compute-sdk-go/geo"
+)
+
+// fakeGeo makes geoLookup return g, or fail if g is nil, for the rest of
+// the test.
+func fakeGeo(t *testing.T, g *geo.Geo) {
+	saved := geoLookup
+	t.Cleanup(func() { geoLookup = saved })
+	geoLookup = func(ip net.IP) (*geo.Geo, error) {
+		if g == nil {
+			return nil, errors.New("no geo data")
+		}
+		return g, nil
+	}
+}
+
+func TestGeo(t *testing.T) {
+	tests := []struct {
+		name string
+		geo  *geo.Geo
+		want map[string]interface{}
+	}{
+		{
+			name: "full data",
+			geo:  &geo.Geo{CountryCode: "GB", City: "London", Region: "ENG", Latitude: 51.5, Longitude: -0.12, AsNumber: 64496, AsName: "Example"},
+			want: map[string]interface{}{
+				"ip": "192.0.2.1", "country": "GB", "city": "London", "region": "ENG",
+				"latitude": 51.5, "longitude": -0.12, "asn": 64496.0, "as_name": "Example",
+			},
+		},
+		{
+			name: "country only",
+			geo:  &geo.Geo{CountryCode: "GB"},
+			want: map[string]interface{}{
+				"ip": "192.0.2.1", "country": "GB", "city": nil, "region": nil,
+				"latitude": 0.0, "longitude": 0.0, "asn": 0.0, "as_name": nil,
+			},
+		},
+		{
+			name: "lookup fails",
+			want: map[string]interface{}{
+				"ip": "192.0.2.1", "country": nil, "city": nil, "region": nil,
+				"latitude": nil, "longitude": nil, "asn": nil, "as_name": nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		fakeGeo(t, tt.geo)
+		rec := serve(t, "GET", "/geo", nil)
+		if rec.status != 200 {
+			t.Errorf("%s: status %d, want 200", tt.name, rec.status)
+		}
+		var got map[string]interface{}
+		if err := json.Unmarshal(rec.body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
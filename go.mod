@@ -2,4 +2,7 @@ module compute-starter-kit-go
 
 go 1.17
 
-require github.com/fastly/compute-sdk-go v0.1.2
+require (
+	github.com/andybalholm/brotli v1.1.0
+	github.com/fastly/compute-sdk-go v0.1.2
+)
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+const defaultDecompressRatioMax = 100
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// handleGzipBombSafe reports the decompressed size of a gzip request body.
+// The body is inflated a chunk at a time and abandoned with a 400 as soon as
+// it has expanded more than decompress_ratio_max times, so a zip bomb costs
+// no more than a chunk of memory and the time to spot it.
+func handleGzipBombSafe(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	if enc := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); enc != "gzip" && enc != "x-gzip" {
+		fsthttp.Error(w, "Request body must be gzip encoded", fsthttp.StatusUnsupportedMediaType)
+		return
+	}
+	ratioMax := int64(configInt("decompress_ratio_max", defaultDecompressRatioMax))
+
+	compressed := &countingReader{r: r.Body}
+	zr, err := gzip.NewReader(compressed)
+	if err != nil {
+		fsthttp.Error(w, err.Error(), fsthttp.StatusBadRequest)
+		return
+	}
+
+	var decompressed int64
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := zr.Read(buf)
+		decompressed += int64(n)
+		if decompressed > ratioMax*compressed.n {
+			fsthttp.Error(w, fmt.Sprintf("Decompression ratio exceeds %d", ratioMax), fsthttp.StatusBadRequest)
+			return
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fsthttp.Error(w, err.Error(), fsthttp.StatusBadRequest)
+			return
+		}
+	}
+
+	writeJSON(w, r, fsthttp.StatusOK, map[string]interface{}{
+		"compressed_size":   compressed.n,
+		"decompressed_size": decompressed,
+		"ratio":             float64(decompressed) / float64(compressed.n),
+		"ratio_max":         ratioMax,
+	})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func gzipped(t *testing.T, data []byte) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	zw.Close()
+	return &buf
+}
+
+func TestGzipBombSafe(t *testing.T) {
+	data := []byte(strings.Repeat("edgehttpbin ", 100))
+	body := gzipped(t, data)
+	compressedSize := body.Len()
+	rec := serve(t, "POST", "/gzip-bomb-safe", body, "Content-Encoding", "gzip")
+	if rec.status != 200 {
+		t.Fatalf("status %d, want 200: %s", rec.status, rec.body.String())
+	}
+	var got struct {
+		Compressed   int `json:"compressed_size"`
+		Decompressed int `json:"decompressed_size"`
+	}
+	if err := json.Unmarshal(rec.body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Compressed != compressedSize || got.Decompressed != len(data) {
+		t.Errorf("sizes %+v, want %d and %d", got, compressedSize, len(data))
+	}
+}
+
+func TestGzipBombSafeRejects(t *testing.T) {
+	// Ten megabytes of zeros compress to about ten kilobytes.
+	bomb := gzipped(t, make([]byte, 10<<20))
+	if rec := serve(t, "POST", "/gzip-bomb-safe", bomb, "Content-Encoding", "gzip"); rec.status != 400 {
+		t.Errorf("bomb: status %d, want 400", rec.status)
+	}
+
+	fakeConfig(t, map[string]string{"decompress_ratio_max": "2000"})
+	bomb = gzipped(t, make([]byte, 10<<20))
+	if rec := serve(t, "POST", "/gzip-bomb-safe", bomb, "Content-Encoding", "gzip"); rec.status != 200 {
+		t.Errorf("bomb under a raised ratio: status %d, want 200", rec.status)
+	}
+
+	if rec := serve(t, "POST", "/gzip-bomb-safe", strings.NewReader("not gzip"), "Content-Encoding", "gzip"); rec.status != 400 {
+		t.Errorf("corrupt body: status %d, want 400", rec.status)
+	}
+	if rec := serve(t, "POST", "/gzip-bomb-safe", strings.NewReader("plain")); rec.status != 415 {
+		t.Errorf("unencoded body: status %d, want 415", rec.status)
+	}
+}
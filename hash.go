@@ -0,0 +1,38 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"strings"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// hashAlgorithms are the algorithms /hash can compute.
+var hashAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// handleHash serves /hash/{algo}/{value}, returning the hex digest of value.
+// Everything after the algorithm is hashed, slashes included.
+func handleHash(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	parts := strings.SplitN(r.URL.Path, "/", 4)
+	if len(parts) != 4 {
+		fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
+		return
+	}
+
+	algo := strings.ToLower(parts[2])
+	h, ok := hashAlgorithms[algo]
+	if !ok {
+		fsthttp.Error(w, "Invalid algorithm, must be md5, sha1, sha256 or sha512", fsthttp.StatusBadRequest)
+		return
+	}
+	writeJSON(w, r, fsthttp.StatusOK, map[string]string{"algo": algo, "hex": hexHash(h, parts[3])})
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	tests := []struct {
+		target, algo, hex string
+	}{
+		{"/hash/md5/abc", "md5", "900150983cd24fb0d6963f7d28e17f72"},
+		{"/hash/sha1/abc", "sha1", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{"/hash/SHA256/abc", "sha256", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"/hash/sha512/abc", "sha512", "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f"},
+		{"/hash/md5/a/b", "md5", "a7e86136543b019d72468ceebf71fb8e"},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", tt.target, nil)
+		var got map[string]string
+		if err := json.Unmarshal(rec.body.Bytes(), &got); err != nil {
+			t.Fatalf("GET %s: %v", tt.target, err)
+		}
+		if got["algo"] != tt.algo || got["hex"] != tt.hex {
+			t.Errorf("GET %s: %v, want %s %s", tt.target, got, tt.algo, tt.hex)
+		}
+	}
+
+	if rec := serve(t, "GET", "/hash/crc32/abc", nil); rec.status != 400 {
+		t.Errorf("unknown algo: status %d, want 400", rec.status)
+	}
+}
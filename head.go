@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// headResponseWriter runs a GET handler for a HEAD request. The body is
+// counted but never sent, and the response is only started once the handler
+// returns so the Content-Length can reflect what a GET would have sent.
+type headResponseWriter struct {
+	fsthttp.ResponseWriter
+	status int
+	length int
+}
+
+func newHeadResponseWriter(w fsthttp.ResponseWriter) *headResponseWriter {
+	return &headResponseWriter{ResponseWriter: w}
+}
+
+func (hw *headResponseWriter) WriteHeader(code int) {
+	if hw.status == 0 {
+		hw.status = code
+	}
+}
+
+func (hw *headResponseWriter) Write(p []byte) (int, error) {
+	hw.WriteHeader(fsthttp.StatusOK)
+	hw.length += len(p)
+	return len(p), nil
+}
+
+func (hw *headResponseWriter) Close() error {
+	hw.WriteHeader(fsthttp.StatusOK)
+	return nil
+}
+
+// finish sends the response headers. Manual framing keeps the
+// Content-Length intact even though no body follows. A chunked GET has no
+// Content-Length, so neither does its HEAD.
+func (hw *headResponseWriter) finish() {
+	hw.WriteHeader(fsthttp.StatusOK)
+	if hw.Header().Get("Content-Length") == "" && hw.Header().Get("Transfer-Encoding") == "" {
+		hw.Header().Set("Content-Length", strconv.Itoa(hw.length))
+	}
+	hw.ResponseWriter.SetManualFramingMode(true)
+	hw.ResponseWriter.WriteHeader(hw.status)
+}
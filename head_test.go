@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestHead(t *testing.T) {
+	tests := []struct {
+		target, contentType, length string
+	}{
+		{"/bytes/1024", "application/octet-stream", "1024"},
+		{"/json", "application/json; charset=utf-8", ""},
+	}
+	for _, tt := range tests {
+		get := serve(t, "GET", tt.target, nil)
+		rec := serve(t, "HEAD", tt.target, nil)
+		if rec.status != 200 {
+			t.Errorf("HEAD %s: status %d, want 200", tt.target, rec.status)
+		}
+		if rec.body.Len() != 0 {
+			t.Errorf("HEAD %s: %d byte body, want none", tt.target, rec.body.Len())
+		}
+		if got := rec.header.Get("Content-Type"); got != tt.contentType {
+			t.Errorf("HEAD %s: Content-Type %q, want %q", tt.target, got, tt.contentType)
+		}
+		length := tt.length
+		if length == "" {
+			length = get.header.Get("Content-Length")
+		}
+		if got := rec.header.Get("Content-Length"); got != length {
+			t.Errorf("HEAD %s: Content-Length %q, want %q", tt.target, got, length)
+		}
+	}
+}
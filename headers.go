@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// forbiddenResponseHeaders can't be set through /response-headers as they
+// control framing or the connection rather than the response itself.
+var forbiddenResponseHeaders = map[string]bool{
+	"Connection":          true,
+	"Content-Length":      true,
+	"Host":                true,
+	"Keep-Alive":          true,
+	"Proxy-Authenticate":  true,
+	"Proxy-Authorization": true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+}
+
+// handleHeaders returns the request headers as httpbin does, with a header
+// sent more than once listed as an array of its values. ?show= limits them to
+// a comma separated list of names and ?hide= leaves the listed names out.
+func handleHeaders(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	query := r.URL.Query()
+	show, hide := headerNames(query["show"]), headerNames(query["hide"])
+
+	headers := map[string][]string{}
+	for key, values := range r.Header {
+		key = fsthttp.CanonicalHeaderKey(key)
+		if (len(show) > 0 && !show[key]) || hide[key] {
+			continue
+		}
+		headers[key] = values
+	}
+
+	writeJSON(w, r, fsthttp.StatusOK, map[string]interface{}{"headers": flattenValues(headers)})
+}
+
+// headerNames collects the canonical header names from comma separated lists.
+func headerNames(lists []string) map[string]bool {
+	names := map[string]bool{}
+	for _, list := range lists {
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names[fsthttp.CanonicalHeaderKey(name)] = true
+			}
+		}
+	}
+	return names
+}
+
+// validHeaderValue reports whether v can be sent as a header value. CR, LF
+// and the other control characters are refused, as they would let a value
+// end the header early and add headers or a body of its own.
+func validHeaderValue(v string) bool {
+	for i := 0; i < len(v); i++ {
+		if c := v[i]; (c < ' ' && c != '\t') || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// handleResponseHeaders sets each query parameter as a response header and
+// returns them in the body too, as httpbin does.
+func handleResponseHeaders(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	query := r.URL.Query()
+	for key, values := range query {
+		if forbiddenResponseHeaders[fsthttp.CanonicalHeaderKey(key)] {
+			fsthttp.Error(w, fmt.Sprintf("header %s can't be set", key), fsthttp.StatusBadRequest)
+			return
+		}
+		for _, value := range values {
+			if !validHeaderValue(key) || !validHeaderValue(value) {
+				fsthttp.Error(w, fmt.Sprintf("Invalid header %s, control characters aren't allowed", key), fsthttp.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	for key, values := range query {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	body := flattenValues(query)
+	if _, ok := query["Content-Type"]; !ok {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		body["Content-Type"] = "application/json; charset=utf-8"
+	}
+
+	// writeJSON would replace a Content-Type given in the query.
+	data, err := encodeJSON(r, body)
+	if err != nil {
+		fsthttp.Error(w, err.Error(), fsthttp.StatusInternalServerError)
+		return
+	}
+	w.Write(data)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestResponseHeaders(t *testing.T) {
+	for _, method := range []string{"GET", "POST"} {
+		rec := serve(t, method, "/response-headers?foo=1&foo=2", nil)
+		if rec.status != 200 {
+			t.Fatalf("%s: status %d, want 200", method, rec.status)
+		}
+		if got, want := rec.header.Values("Foo"), []string{"1", "2"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: Foo = %q, want %q", method, got, want)
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := body["foo"], []interface{}{"1", "2"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: body foo = %v, want %v", method, got, want)
+		}
+	}
+
+	if rec := serve(t, "GET", "/response-headers?Content-Length=1", nil); rec.status != 400 {
+		t.Errorf("setting Content-Length: status %d, want 400", rec.status)
+	}
+}
+
+func TestHeadersShowHide(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"Accept", "User-Agent", "X-One", "X-Two"}},
+		{"?show=x-one,User-Agent", []string{"User-Agent", "X-One"}},
+		{"?show=x-one&show=x-two", []string{"X-One", "X-Two"}},
+		{"?hide=accept, x-two", []string{"User-Agent", "X-One"}},
+		{"?show=x-one,x-two&hide=x-two", []string{"X-One"}},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", "/headers"+tt.query, nil,
+			"Accept", "*/*", "User-Agent", "test", "X-One", "1", "X-Two", "2")
+		var body struct {
+			Headers map[string]interface{} `json:"headers"`
+		}
+		if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for name := range body.Headers {
+			got = append(got, name)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("/headers%s: %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestResponseHeadersControlCharacters(t *testing.T) {
+	for _, query := range []string{"X-Foo=bar%0d%0aSet-Cookie:%20x=1", "X-Foo=bar%0a", "X-Foo%0d%0aX-Bar=1", "X-Foo=a%7fb"} {
+		rec := serve(t, "GET", "/response-headers?"+query, nil)
+		if rec.status != 400 || rec.header.Get("X-Foo") != "" || rec.header.Get("Set-Cookie") != "" {
+			t.Errorf("?%s: status %d, want 400 and no headers set", query, rec.status)
+		}
+	}
+	if rec := serve(t, "GET", "/response-headers?X-Foo=a%09b", nil); rec.status != 200 || rec.header.Get("X-Foo") != "a\tb" {
+		t.Errorf("tab in a value: status %d, X-Foo %q", rec.status, rec.header.Get("X-Foo"))
+	}
+}
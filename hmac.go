@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// hmacSignatureHeader carries the signature checked by /hmac/{algo}.
+const hmacSignatureHeader = "X-Signature"
+
+// handleHMAC recomputes the HMAC of a request with the hmac_secret secret
+// and compares it with the X-Signature header, for debugging webhook signing.
+// The signed string is the method, the request URI, the Date header and the
+// hex digest of the body, each on its own line, all using the algorithm in
+// the path. The signature may be hex or base64, optionally prefixed with
+// "algo=" as some webhook producers do.
+func handleHMAC(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) != 3 {
+		fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
+		return
+	}
+	algo := strings.ToLower(parts[2])
+	h, ok := hashAlgorithms[algo]
+	if !ok {
+		fsthttp.Error(w, "Invalid algorithm, must be md5, sha1, sha256 or sha512", fsthttp.StatusBadRequest)
+		return
+	}
+	secret, ok := secretValue("hmac_secret")
+	if !ok {
+		fsthttp.Error(w, "HMAC verification isn't configured", fsthttp.StatusServiceUnavailable)
+		return
+	}
+	body, err := readBody(r)
+	if err != nil {
+		bodyError(w, err)
+		return
+	}
+
+	bodyHash := hexHash(h, string(body))
+	canonical := strings.Join([]string{r.Method, r.URL.RequestURI(), r.Header.Get("Date"), bodyHash}, "\n")
+	mac := hmac.New(h, secret)
+	mac.Write([]byte(canonical))
+	expected := mac.Sum(nil)
+
+	presented := strings.TrimSpace(r.Header.Get(hmacSignatureHeader))
+	sig := presented
+	if i := strings.IndexByte(sig, '='); i > 0 && strings.EqualFold(sig[:i], algo) {
+		sig = sig[i+1:]
+	}
+	valid := false
+	if decoded, err := hex.DecodeString(sig); err == nil && hmac.Equal(decoded, expected) {
+		valid = true
+	} else if decoded, err := base64.StdEncoding.DecodeString(sig); err == nil && hmac.Equal(decoded, expected) {
+		valid = true
+	}
+
+	status := fsthttp.StatusOK
+	if !valid {
+		status = fsthttp.StatusUnauthorized
+	}
+	writeJSON(w, r, status, map[string]interface{}{
+		"valid":     valid,
+		"algorithm": algo,
+		"canonical": map[string]string{
+			"method":    r.Method,
+			"uri":       r.URL.RequestURI(),
+			"date":      r.Header.Get("Date"),
+			"body_hash": bodyHash,
+			"string":    canonical,
+		},
+		"expected": map[string]string{
+			"hex":    hex.EncodeToString(expected),
+			"base64": base64.StdEncoding.EncodeToString(expected),
+		},
+		"presented": presented,
+	})
+}
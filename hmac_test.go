@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const hmacTestDate = "Tue, 13 Oct 2026 12:00:00 GMT"
+
+// hmacSignature signs a request the way /hmac/sha256 expects.
+func hmacSignature(secret, method, uri, body string) []byte {
+	bodyHash := sha256.Sum256([]byte(body))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join([]string{method, uri, hmacTestDate, hex.EncodeToString(bodyHash[:])}, "\n")))
+	return mac.Sum(nil)
+}
+
+func TestHMAC(t *testing.T) {
+	fakeSecrets(t, map[string]string{"hmac_secret": "webhook-secret"})
+	body := `{"event":"push"}`
+	sig := hmacSignature("webhook-secret", "POST", "/hmac/sha256?delivery=1", body)
+	wrong := hmacSignature("other-secret", "POST", "/hmac/sha256?delivery=1", body)
+
+	tests := []struct {
+		name, signature string
+		status          int
+	}{
+		{"hex", hex.EncodeToString(sig), 200},
+		{"upper case hex", strings.ToUpper(hex.EncodeToString(sig)), 200},
+		{"base64", base64.StdEncoding.EncodeToString(sig), 200},
+		{"prefixed", "sha256=" + hex.EncodeToString(sig), 200},
+		{"prefixed base64", "SHA256=" + base64.StdEncoding.EncodeToString(sig), 200},
+		{"wrong secret", hex.EncodeToString(wrong), 401},
+		{"truncated", hex.EncodeToString(sig[:16]), 401},
+		{"other prefix", "sha1=" + hex.EncodeToString(sig), 401},
+		{"missing", "", 401},
+	}
+	for _, tt := range tests {
+		header := []string{"Date", hmacTestDate}
+		if tt.signature != "" {
+			header = append(header, hmacSignatureHeader, tt.signature)
+		}
+		rec := serve(t, "POST", "/hmac/sha256?delivery=1", strings.NewReader(body), header...)
+		if rec.status != tt.status {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, rec.status, tt.status, rec.body.String())
+			continue
+		}
+		var resp struct {
+			Valid    bool              `json:"valid"`
+			Expected map[string]string `json:"expected"`
+		}
+		if err := json.Unmarshal(rec.body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if resp.Valid != (tt.status == 200) || resp.Expected["hex"] != hex.EncodeToString(sig) {
+			t.Errorf("%s: valid %v, expected %s", tt.name, resp.Valid, resp.Expected["hex"])
+		}
+	}
+}
+
+func TestHMACErrors(t *testing.T) {
+	if rec := serve(t, "POST", "/hmac/sha256", nil); rec.status != 503 {
+		t.Errorf("without hmac_secret: status %d, want 503", rec.status)
+	}
+
+	fakeSecrets(t, map[string]string{"hmac_secret": "webhook-secret"})
+	tests := []struct {
+		target string
+		status int
+	}{
+		{"/hmac/sha3", 400},
+		{"/hmac/", 400},
+		{"/hmac/sha256/extra", 404},
+	}
+	for _, tt := range tests {
+		if rec := serve(t, "POST", tt.target, nil); rec.status != tt.status {
+			t.Errorf("POST %s: status %d, want %d", tt.target, rec.status, tt.status)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"html/template"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// htmlTemplate renders the title and body given to /html. html/template
+// escapes both, so markup in the parameters is shown rather than run.
+var htmlTemplate = template.Must(template.New("html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Body}}</p>
+</body>
+</html>
+`))
+
+func handleHTML(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	query := r.URL.Query()
+	title, body := query.Get("title"), query.Get("body")
+	if title == "" && body == "" {
+		serveStatic(w, "moby.html", "text/html; charset=utf-8")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	htmlTemplate.Execute(w, struct{ Title, Body string }{title, body})
+}
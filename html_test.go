@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestHTMLEscapes(t *testing.T) {
+	query := url.Values{
+		"title": {"<b>Hi</b>"},
+		"body":  {`<script>alert("x")</script>`},
+	}
+	rec := serve(t, "GET", "/html?"+query.Encode(), nil)
+	if rec.status != 200 || rec.header.Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Fatalf("status %d, Content-Type %q", rec.status, rec.header.Get("Content-Type"))
+	}
+	page := rec.body.String()
+	if strings.Contains(page, "<script>") || strings.Contains(page, "<b>") {
+		t.Errorf("markup in the parameters was not escaped:\n%s", page)
+	}
+	for _, want := range []string{"<title>&lt;b&gt;Hi&lt;/b&gt;</title>", "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page is missing %s", want)
+		}
+	}
+
+	if rec := serve(t, "GET", "/html", nil); !strings.Contains(rec.body.String(), "Herman Melville") {
+		t.Error("/html without parameters is not the Moby Dick page")
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// imageFormats maps the /image/{format} path segment to the embedded sample
+// and its media type. The order of imageFormatOrder is used when the client
+// accepts any image type.
+var imageFormats = map[string]struct {
+	file        string
+	contentType string
+}{
+	"png":  {"sample.png", "image/png"},
+	"jpeg": {"sample.jpeg", "image/jpeg"},
+	"svg":  {"sample.svg", "image/svg+xml"},
+	"webp": {"sample.webp", "image/webp"},
+}
+
+var imageFormatOrder = []string{"png", "jpeg", "webp", "svg"}
+
+func handleImage(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	switch len(parts) {
+	case 2:
+		format, ok := negotiateImage(r.Header.Get("Accept"))
+		if !ok {
+			fsthttp.Error(w, fsthttp.StatusText(406), fsthttp.StatusNotAcceptable)
+			return
+		}
+		serveImage(w, format)
+	case 3:
+		if _, ok := imageFormats[parts[2]]; !ok {
+			fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
+			return
+		}
+		if seed := r.URL.Query().Get("seed"); parts[2] == "svg" && seed != "" {
+			n, err := strconv.ParseInt(seed, 10, 64)
+			if err != nil {
+				fsthttp.Error(w, "Invalid seed", fsthttp.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", imageFormats["svg"].contentType)
+			w.Write([]byte(generateSVG(n)))
+			return
+		}
+		serveImage(w, parts[2])
+	default:
+		fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
+	}
+}
+
+func serveImage(w fsthttp.ResponseWriter, format string) {
+	f := imageFormats[format]
+	serveStatic(w, path.Join("images", f.file), f.contentType)
+}
+
+// negotiateImage picks the image format preferred by the Accept header,
+// honouring q-values. An empty Accept header accepts anything.
+func negotiateImage(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return imageFormatOrder[0], true
+	}
+
+	type mediaRange struct {
+		typ string
+		q   float64
+	}
+	var ranges []mediaRange
+	for _, entry := range strings.Split(accept, ",") {
+		fields := strings.Split(entry, ";")
+		mr := mediaRange{typ: strings.ToLower(strings.TrimSpace(fields[0])), q: 1}
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					mr.q = q
+				}
+			}
+		}
+		if mr.q > 0 {
+			ranges = append(ranges, mr)
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, mr := range ranges {
+		if mr.typ == "*/*" || mr.typ == "image/*" {
+			return imageFormatOrder[0], true
+		}
+		for _, format := range imageFormatOrder {
+			if imageFormats[format].contentType == mr.typ {
+				return format, true
+			}
+		}
+	}
+	return "", false
+}
+
+// generateSVG draws a pattern of circles and squares from a PRNG seeded with
+// seed, so the same seed always produces byte-identical output.
+func generateSVG(seed int64) string {
+	rng := rand.New(rand.NewSource(seed))
+	color := func() string {
+		return fmt.Sprintf("#%02x%02x%02x", rng.Intn(256), rng.Intn(256), rng.Intn(256))
+	}
+
+	var b strings.Builder
+	b.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" width="100" height="100" viewBox="0 0 100 100">`)
+	fmt.Fprintf(&b, `<rect width="100" height="100" fill="%s"/>`, color())
+	for i := 0; i < 12; i++ {
+		x, y, size := rng.Intn(100), rng.Intn(100), 5+rng.Intn(20)
+		if i%2 == 0 {
+			fmt.Fprintf(&b, `<circle cx="%d" cy="%d" r="%d" fill="%s" fill-opacity="0.8"/>`, x, y, size, color())
+		} else {
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" fill-opacity="0.8"/>`, x, y, size, size, color())
+		}
+	}
+	b.WriteString(`</svg>`)
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+)
+
+func TestImageNegotiation(t *testing.T) {
+	tests := []struct {
+		accept, contentType string
+	}{
+		{"", "image/png"},
+		{"image/webp", "image/webp"},
+		{"image/svg+xml", "image/svg+xml"},
+		{"image/jpeg;q=0.5, image/webp;q=0.9", "image/webp"},
+		{"text/html, image/*;q=0.8", "image/png"},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", "/image", nil, "Accept", tt.accept)
+		if rec.status != 200 {
+			t.Errorf("Accept %q: status %d, want 200", tt.accept, rec.status)
+		}
+		if got := rec.header.Get("Content-Type"); got != tt.contentType {
+			t.Errorf("Accept %q: Content-Type %q, want %q", tt.accept, got, tt.contentType)
+		}
+		if rec.body.Len() == 0 {
+			t.Errorf("Accept %q: empty body", tt.accept)
+		}
+	}
+
+	rec := serve(t, "GET", "/image", nil, "Accept", "text/html")
+	if rec.status != 406 {
+		t.Errorf("Accept text/html: status %d, want 406", rec.status)
+	}
+}
+
+func TestImageFormat(t *testing.T) {
+	for format, f := range imageFormats {
+		rec := serve(t, "GET", "/image/"+format, nil)
+		if rec.status != 200 || rec.header.Get("Content-Type") != f.contentType {
+			t.Errorf("GET /image/%s: status %d, Content-Type %q", format, rec.status, rec.header.Get("Content-Type"))
+		}
+	}
+	if rec := serve(t, "GET", "/image/gif", nil); rec.status != 404 {
+		t.Errorf("GET /image/gif: status %d, want 404", rec.status)
+	}
+}
+
+func TestImageSVGSeed(t *testing.T) {
+	first := serve(t, "GET", "/image/svg?seed=42", nil)
+	second := serve(t, "GET", "/image/svg?seed=42", nil)
+	if first.status != 200 || second.status != 200 {
+		t.Fatalf("status %d and %d, want 200", first.status, second.status)
+	}
+	if !bytes.Equal(first.body.Bytes(), second.body.Bytes()) {
+		t.Error("seed 42 produced different images")
+	}
+	if other := serve(t, "GET", "/image/svg?seed=43", nil); bytes.Equal(first.body.Bytes(), other.body.Bytes()) {
+		t.Error("seeds 42 and 43 produced the same image")
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(first.body.Bytes()))
+	for {
+		if _, err := dec.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("seeded SVG is not well-formed XML: %v", err)
+		}
+	}
+
+	if rec := serve(t, "GET", "/image/svg?seed=abc", nil); rec.status != 400 {
+		t.Errorf("seed=abc: status %d, want 400", rec.status)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// requestInfo is the httpbin description of a request shared by the
+// endpoints that echo what they received.
+type requestInfo struct {
+	Args      map[string]interface{} `json:"args"`
+	Headers   map[string]interface{} `json:"headers"`
+	Origin    string                 `json:"origin"`
+	URL       string                 `json:"url"`
+	RequestID string                 `json:"request_id"`
+}
+
+func newRequestInfo(r *fsthttp.Request) requestInfo {
+	return requestInfo{
+		Args:      flattenValues(r.URL.Query()),
+		Headers:   flattenValues(r.Header),
+		Origin:    clientIP(r),
+		URL:       r.URL.String(),
+		RequestID: requestID(r),
+	}
+}
+
+// flattenValues turns single valued entries into plain strings and keeps
+// repeated ones as lists, as httpbin does.
+func flattenValues(values map[string][]string) map[string]interface{} {
+	flat := make(map[string]interface{}, len(values))
+	for key, v := range values {
+		if len(v) == 1 {
+			flat[key] = v[0]
+		} else {
+			flat[key] = v
+		}
+	}
+	return flat
+}
+
+// handleGet returns the request's query arguments, headers, origin and URL.
+func handleGet(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	writeJSON(w, r, fsthttp.StatusOK, newRequestInfo(r))
+}
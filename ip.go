@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net"
+	"strings"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+func handleIP(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	writeJSON(w, r, fsthttp.StatusOK, map[string]string{"origin": clientIP(r)})
+}
+
+// clientIP returns the address of the client, preferring Fastly-Client-IP
+// and then the leftmost X-Forwarded-For entry over the connection address.
+func clientIP(r *fsthttp.Request) string {
+	if ip := strings.TrimSpace(r.Header.Get("Fastly-Client-IP")); ip != "" {
+		return ip
+	}
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		if ip := strings.TrimSpace(strings.Split(xff, ",")[0]); ip != "" {
+			return ip
+		}
+	}
+	return stripPort(r.RemoteAddr)
+}
+
+// stripPort removes the port from addr, if it has one.
+func stripPort(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return strings.Trim(addr, "[]")
+	}
+	return host
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+func TestIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     []string
+		want       string
+	}{
+		{"address with port", "192.0.2.1:4321", nil, "192.0.2.1"},
+		{"address without port", "192.0.2.1", nil, "192.0.2.1"},
+		{"IPv6 with port", "[2001:db8::1]:4321", nil, "2001:db8::1"},
+		{"X-Forwarded-For", "192.0.2.1:4321", []string{"X-Forwarded-For", "198.51.100.7, 203.0.113.9"}, "198.51.100.7"},
+		{"Fastly-Client-IP", "192.0.2.1:4321", []string{"Fastly-Client-IP", "203.0.113.5", "X-Forwarded-For", "198.51.100.7"}, "203.0.113.5"},
+	}
+	for _, tt := range tests {
+		req, _ := fsthttp.NewRequest("GET", "/ip", nil)
+		req.RemoteAddr = tt.remoteAddr
+		for i := 0; i+1 < len(tt.header); i += 2 {
+			req.Header.Set(tt.header[i], tt.header[i+1])
+		}
+		rec := newResponseRecorder()
+		handler(context.Background(), rec, req)
+
+		var body map[string]string
+		if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body["origin"] != tt.want {
+			t.Errorf("%s: origin %q, want %q", tt.name, body["origin"], tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+const (
+	maxJSONIndent = 8
+	jsonChunkSize = 64
+)
+
+// writeJSON writes v as the JSON body of a response with the given status.
+// The output is indented when the request asks for it with ?pretty=true or
+// ?indent=n, which is easier to read when debugging by hand. ?chunked=true
+// sends the body as several separate writes with no Content-Length, for
+// testing clients against chunked responses.
+func writeJSON(w fsthttp.ResponseWriter, r *fsthttp.Request, status int, v interface{}) {
+	data, err := encodeJSON(r, v)
+	if err != nil {
+		fsthttp.Error(w, err.Error(), fsthttp.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if wantChunked(r) {
+		writeChunked(w, status, data)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// wantChunked reports whether r asks for a chunked body with ?chunked=true.
+func wantChunked(r *fsthttp.Request) bool {
+	chunked, _ := strconv.ParseBool(r.URL.Query().Get("chunked"))
+	return chunked
+}
+
+// writeChunked sends data as several separate writes with chunked framing.
+// The SDK normally replaces the framing headers with its own, so manual
+// framing is switched on to keep Transfer-Encoding and leave out any
+// Content-Length.
+func writeChunked(w fsthttp.ResponseWriter, status int, data []byte) {
+	w.Header().Del("Content-Length")
+	w.Header().Set("Transfer-Encoding", "chunked")
+	w.SetManualFramingMode(true)
+	w.WriteHeader(status)
+	for len(data) > 0 {
+		n := jsonChunkSize
+		if n > len(data) {
+			n = len(data)
+		}
+		w.Write(data[:n])
+		data = data[n:]
+	}
+}
+
+// encodeJSON encodes v as writeJSON does, indented as r asks and without
+// escaping HTML characters.
+func encodeJSON(r *fsthttp.Request, v interface{}) ([]byte, error) {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", jsonIndent(r))
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
+
+// jsonIndent returns the indentation requested by r, or "" for compact
+// output. ?indent= takes precedence over ?pretty= and is capped at
+// maxJSONIndent spaces.
+func jsonIndent(r *fsthttp.Request) string {
+	query := r.URL.Query()
+	if n, err := strconv.Atoi(query.Get("indent")); err == nil && n > 0 {
+		if n > maxJSONIndent {
+			n = maxJSONIndent
+		}
+		return strings.Repeat(" ", n)
+	}
+	if pretty, _ := strconv.ParseBool(query.Get("pretty")); pretty {
+		return "  "
+	}
+	return ""
+}
+
+func handleJSON(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	data, err := staticAssets.ReadFile("static/sample.json")
+	if err != nil {
+		fsthttp.Error(w, fsthttp.StatusText(500), 500)
+		return
+	}
+	writeJSON(w, r, fsthttp.StatusOK, json.RawMessage(data))
+}
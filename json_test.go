@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+func TestJSONIndent(t *testing.T) {
+	tests := []struct {
+		query, indent string
+	}{
+		{"", ""},
+		{"?pretty=true", "  "},
+		{"?pretty=false", ""},
+		{"?indent=4", "    "},
+		{"?indent=4&pretty=true", "    "},
+		{"?indent=99", strings.Repeat(" ", maxJSONIndent)},
+		{"?indent=-1", ""},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", "/uuid"+tt.query, nil)
+		body := rec.body.String()
+		if !json.Valid(rec.body.Bytes()) {
+			t.Errorf("/uuid%s: invalid JSON %q", tt.query, body)
+		}
+		want := "{"
+		if tt.indent != "" {
+			want = "{\n" + tt.indent + `"uuid"`
+		}
+		if !strings.HasPrefix(body, want) || (tt.indent == "" && strings.Contains(body, "\n ")) {
+			t.Errorf("/uuid%s: body %q, want it to start %q", tt.query, body, want)
+		}
+	}
+
+	// HTML characters are left as they are.
+	if rec := serve(t, "GET", "/anything?q=<a>&pretty=true", nil); !strings.Contains(rec.body.String(), `"q": "<a>"`) {
+		t.Errorf("pretty /anything: %s", rec.body.String())
+	}
+}
+
+// framingRecorder notes whether manual framing was switched on, and how many
+// writes made up the body.
+type framingRecorder struct {
+	*responseRecorder
+	manualFraming bool
+	writes        int
+}
+
+func (rec *framingRecorder) SetManualFramingMode(mode bool) {
+	rec.manualFraming = mode
+}
+
+func (rec *framingRecorder) Write(p []byte) (int, error) {
+	rec.writes++
+	return rec.responseRecorder.Write(p)
+}
+
+func serveFraming(t *testing.T, target string, header ...string) *framingRecorder {
+	t.Helper()
+	req, err := fsthttp.NewRequest("GET", target, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := &framingRecorder{responseRecorder: newResponseRecorder()}
+	handler(context.Background(), rec, req)
+	return rec
+}
+
+func TestJSONChunked(t *testing.T) {
+	rec := serveFraming(t, "/anything?chunked=true&pad="+strings.Repeat("x", 200))
+	if !rec.manualFraming || rec.header.Get("Transfer-Encoding") != "chunked" || rec.header.Get("Content-Length") != "" {
+		t.Errorf("manual framing %v, Transfer-Encoding %q, Content-Length %q", rec.manualFraming, rec.header.Get("Transfer-Encoding"), rec.header.Get("Content-Length"))
+	}
+	if rec.writes < 2 {
+		t.Errorf("%d writes, want the body split up", rec.writes)
+	}
+	if !json.Valid(rec.body.Bytes()) {
+		t.Errorf("reassembled body is not JSON: %q", rec.body.String())
+	}
+
+	if rec := serveFraming(t, "/anything"); rec.manualFraming || rec.header.Get("Content-Length") != strconv.Itoa(rec.body.Len()) {
+		t.Errorf("unchunked: manual framing %v, Content-Length %q", rec.manualFraming, rec.header.Get("Content-Length"))
+	}
+}
+
+func TestJSONChunkedCompressed(t *testing.T) {
+	rec := serveFraming(t, "/anything?chunked=true&pad="+strings.Repeat("x", 200), "Accept-Encoding", "gzip")
+	if rec.header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding %q, want gzip", rec.header.Get("Content-Encoding"))
+	}
+	if !rec.manualFraming || rec.header.Get("Transfer-Encoding") != "chunked" || rec.header.Get("Content-Length") != "" {
+		t.Errorf("manual framing %v, Transfer-Encoding %q, Content-Length %q", rec.manualFraming, rec.header.Get("Transfer-Encoding"), rec.header.Get("Content-Length"))
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(rec.body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil || !json.Valid(data) {
+		t.Errorf("reassembled body does not decompress to JSON: %v", err)
+	}
+}
+
+func TestJSONChunkedHead(t *testing.T) {
+	req, _ := fsthttp.NewRequest("HEAD", "/uuid?chunked=true", nil)
+	rec := &framingRecorder{responseRecorder: newResponseRecorder()}
+	handler(context.Background(), rec, req)
+	if rec.header.Get("Content-Length") != "" || rec.header.Get("Transfer-Encoding") != "chunked" || rec.body.Len() != 0 {
+		t.Errorf("HEAD: Content-Length %q, Transfer-Encoding %q, %d bytes", rec.header.Get("Content-Length"), rec.header.Get("Transfer-Encoding"), rec.body.Len())
+	}
+}
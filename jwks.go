@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// jwksTTL is how long the edge caches the key set fetched by /jwt/verify.
+const jwksTTL = 5 * time.Minute
+
+// jwk is a single key of a JSON Web Key Set, as described in RFC 7517. Only
+// the members needed for RSA and EC public keys are kept.
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// jwtAlgorithms maps the asymmetric JWS algorithms /jwt/verify checks to the
+// key type they need and the hash they sign.
+var jwtAlgorithms = map[string]struct {
+	kty  string
+	hash crypto.Hash
+	pss  bool
+}{
+	"RS256": {"RSA", crypto.SHA256, false},
+	"RS384": {"RSA", crypto.SHA384, false},
+	"RS512": {"RSA", crypto.SHA512, false},
+	"PS256": {"RSA", crypto.SHA256, true},
+	"PS384": {"RSA", crypto.SHA384, true},
+	"PS512": {"RSA", crypto.SHA512, true},
+	"ES256": {"EC", crypto.SHA256, false},
+	"ES384": {"EC", crypto.SHA384, false},
+	"ES512": {"EC", crypto.SHA512, false},
+}
+
+// jwtCheck is the outcome of one of the checks made by /jwt/verify.
+type jwtCheck struct {
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// handleJWTVerify validates the Bearer token against the key set at the
+// jwks_url config key, fetched through the jwks_backend backend. Besides the
+// signature it checks exp and nbf, and iss and aud when the jwt_issuer and
+// jwt_audience keys are set. The verdict lists every check, and the status
+// is 200 only when they all pass.
+func handleJWTVerify(ctx context.Context, w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	backend, _ := configValue("jwks_backend")
+	jwksURL, _ := configValue("jwks_url")
+	if backend == "" || jwksURL == "" {
+		fsthttp.Error(w, "JWT verification isn't configured", fsthttp.StatusServiceUnavailable)
+		return
+	}
+
+	token, ok := bearerToken(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		w.WriteHeader(fsthttp.StatusUnauthorized)
+		return
+	}
+	t, err := decodeJWT(token)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="`+err.Error()+`"`)
+		fsthttp.Error(w, err.Error(), fsthttp.StatusUnauthorized)
+		return
+	}
+
+	keys, err := fetchJWKS(ctx, backend, jwksURL)
+	if err != nil {
+		fsthttp.Error(w, err.Error(), fsthttp.StatusBadGateway)
+		return
+	}
+
+	now := time.Now()
+	checks := map[string]jwtCheck{
+		"signature":  checkResult(verifyJWTSignature(token, t, keys)),
+		"expiry":     checkResult(checkTimeClaim(t, "exp", func(at time.Time) bool { return now.Before(at) })),
+		"not_before": checkResult(checkTimeClaim(t, "nbf", func(at time.Time) bool { return !now.Before(at) })),
+	}
+	if issuer, _ := configValue("jwt_issuer"); issuer != "" {
+		checks["issuer"] = checkResult(checkIssuer(t, issuer))
+	}
+	if audience, _ := configValue("jwt_audience"); audience != "" {
+		checks["audience"] = checkResult(checkAudience(t, audience))
+	}
+
+	valid := true
+	for _, c := range checks {
+		valid = valid && c.OK
+	}
+	status := fsthttp.StatusOK
+	if !valid {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		status = fsthttp.StatusUnauthorized
+	}
+	writeJSON(w, r, status, map[string]interface{}{
+		"valid":  valid,
+		"checks": checks,
+		"header": t.Header,
+		"claims": t.Claims,
+	})
+}
+
+func checkResult(err error) jwtCheck {
+	if err != nil {
+		return jwtCheck{Detail: err.Error()}
+	}
+	return jwtCheck{OK: true}
+}
+
+// fetchJWKS gets the key set, letting the edge cache it for jwksTTL so a run
+// of verifications doesn't fetch it every time.
+func fetchJWKS(ctx context.Context, backend, jwksURL string) ([]jwk, error) {
+	req, err := fsthttp.NewRequest("GET", jwksURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.CacheOptions.TTL = uint32(jwksTTL / time.Second)
+
+	resp, err := sendUpstream(ctx, req, backend)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != fsthttp.StatusOK {
+		return nil, fmt.Errorf("fetching JWKS: status %d", resp.StatusCode)
+	}
+	data, err := readLimited(resp.Body, maxBody())
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parsing JWKS: %v", err)
+	}
+	return set.Keys, nil
+}
+
+// verifyJWTSignature checks the token was signed by one of the keys, the one
+// named by the kid header if there is one.
+func verifyJWTSignature(token string, t *jwt, keys []jwk) error {
+	algName, _ := t.Header["alg"].(string)
+	alg, ok := jwtAlgorithms[algName]
+	if !ok {
+		return fmt.Errorf("unsupported alg %q", algName)
+	}
+	kid, _ := t.Header["kid"].(string)
+
+	h := alg.hash.New()
+	h.Write([]byte(token[:strings.LastIndexByte(token, '.')]))
+	digest := h.Sum(nil)
+
+	for _, k := range keys {
+		if k.Kty != alg.kty || (kid != "" && k.Kid != kid) || (k.Alg != "" && k.Alg != algName) {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		switch pub := pub.(type) {
+		case *rsa.PublicKey:
+			if alg.pss {
+				err = rsa.VerifyPSS(pub, alg.hash, digest, t.Signature, nil)
+			} else {
+				err = rsa.VerifyPKCS1v15(pub, alg.hash, digest, t.Signature)
+			}
+			if err == nil {
+				return nil
+			}
+		case *ecdsa.PublicKey:
+			// JWS ECDSA signatures are r and s concatenated at the curve's
+			// size, rather than ASN.1.
+			size := (pub.Curve.Params().BitSize + 7) / 8
+			if len(t.Signature) == 2*size {
+				r := new(big.Int).SetBytes(t.Signature[:size])
+				s := new(big.Int).SetBytes(t.Signature[size:])
+				if ecdsa.Verify(pub, digest, r, s) {
+					return nil
+				}
+			}
+		}
+	}
+	if kid != "" {
+		return fmt.Errorf("no key with kid %q verifies the signature", kid)
+	}
+	return errors.New("no key verifies the signature")
+}
+
+// publicKey builds the RSA or EC public key described by k.
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err1 := base64.RawURLEncoding.DecodeString(k.N)
+		e, err2 := base64.RawURLEncoding.DecodeString(k.E)
+		if err1 != nil || err2 != nil || len(e) > 4 {
+			return nil, errors.New("malformed RSA key")
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err1 := base64.RawURLEncoding.DecodeString(k.X)
+		y, err2 := base64.RawURLEncoding.DecodeString(k.Y)
+		if err1 != nil || err2 != nil {
+			return nil, errors.New("malformed EC key")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported kty %q", k.Kty)
+}
+
+// checkTimeClaim applies ok to the time in a NumericDate claim. A missing
+// claim passes.
+func checkTimeClaim(t *jwt, name string, ok func(time.Time) bool) error {
+	v, present := t.Claims[name]
+	if !present {
+		return nil
+	}
+	secs, isNum := v.(float64)
+	if !isNum {
+		return fmt.Errorf("%s claim must be a number", name)
+	}
+	at := time.Unix(int64(secs), 0).UTC()
+	if !ok(at) {
+		return fmt.Errorf("%s is %s", name, at.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func checkIssuer(t *jwt, issuer string) error {
+	if iss, _ := t.Claims["iss"].(string); iss != issuer {
+		return fmt.Errorf("iss is %q, expected %q", iss, issuer)
+	}
+	return nil
+}
+
+// checkAudience checks the aud claim, a string or an array of them, names
+// audience.
+func checkAudience(t *jwt, audience string) error {
+	switch aud := t.Claims["aud"].(type) {
+	case string:
+		if aud == audience {
+			return nil
+		}
+	case []interface{}:
+		for _, a := range aud {
+			if a == audience {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("aud doesn't include %q", audience)
+}
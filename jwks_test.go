@@ -0,0 +1,216 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+var (
+	testKeysOnce sync.Once
+	testRSAKey   *rsa.PrivateKey
+	testECKey    *ecdsa.PrivateKey
+)
+
+// testKeys returns an RSA and an EC key, generated once for the package.
+func testKeys(t *testing.T) (*rsa.PrivateKey, *ecdsa.PrivateKey) {
+	t.Helper()
+	testKeysOnce.Do(func() {
+		var err error
+		if testRSAKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			t.Fatal(err)
+		}
+		if testECKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+	})
+	return testRSAKey, testECKey
+}
+
+// testJWKS is the key set holding the public halves of testKeys.
+func testJWKS(t *testing.T) []jwk {
+	rsaKey, ecKey := testKeys(t)
+	enc := base64.RawURLEncoding.EncodeToString
+	return []jwk{
+		{Kid: "rsa-1", Kty: "RSA", Alg: "RS256", N: enc(rsaKey.N.Bytes()), E: enc(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{Kid: "ec-1", Kty: "EC", Crv: "P-256", X: enc(ecKey.X.FillBytes(make([]byte, 32))), Y: enc(ecKey.Y.FillBytes(make([]byte, 32)))},
+	}
+}
+
+// signedJWT builds a token from header and claims, signed by sign over the
+// signing input.
+func signedJWT(t *testing.T, header, claims map[string]interface{}, sign func(input []byte) []byte) string {
+	t.Helper()
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	return input + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(input)))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey) func([]byte) []byte {
+	return func(input []byte) []byte {
+		digest := sha256.Sum256(input)
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	}
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey) func([]byte) []byte {
+	return func(input []byte) []byte {
+		digest := sha256.Sum256(input)
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+}
+
+// fakeJWKS configures /jwt/verify with a stubbed key set fetch serving
+// testJWKS.
+func fakeJWKS(t *testing.T, config map[string]string) {
+	values := map[string]string{"jwks_backend": "idp", "jwks_url": "https://idp.test/.well-known/jwks.json"}
+	for k, v := range config {
+		values[k] = v
+	}
+	fakeConfig(t, values)
+	data, _ := json.Marshal(map[string]interface{}{"keys": testJWKS(t)})
+	fakeUpstream(t, func() *fsthttp.Response {
+		return &fsthttp.Response{StatusCode: 200, Header: fsthttp.NewHeader(), Body: io.NopCloser(strings.NewReader(string(data)))}
+	})
+}
+
+func TestJWTVerify(t *testing.T) {
+	fakeJWKS(t, map[string]string{"jwt_issuer": "https://idp.test/", "jwt_audience": "edgehttpbin"})
+	rsaKey, ecKey := testKeys(t)
+	claims := map[string]interface{}{
+		"iss": "https://idp.test/",
+		"aud": []string{"other", "edgehttpbin"},
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"nbf": time.Now().Add(-time.Minute).Unix(),
+	}
+
+	tokens := map[string]string{
+		"RS256":             signedJWT(t, map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}, claims, signRS256(t, rsaKey)),
+		"ES256":             signedJWT(t, map[string]interface{}{"alg": "ES256", "kid": "ec-1"}, claims, signES256(t, ecKey)),
+		"ES256 without kid": signedJWT(t, map[string]interface{}{"alg": "ES256"}, claims, signES256(t, ecKey)),
+	}
+	for name, token := range tokens {
+		rec := serve(t, "GET", "/jwt/verify", nil, "Authorization", "Bearer "+token)
+		if rec.status != 200 {
+			t.Errorf("%s: status %d, want 200: %s", name, rec.status, rec.body.String())
+		}
+	}
+}
+
+func TestJWTVerifyRejects(t *testing.T) {
+	fakeJWKS(t, nil)
+	rsaKey, ecKey := testKeys(t)
+	claims := map[string]interface{}{"sub": "user"}
+	valid := signedJWT(t, map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}, claims, signRS256(t, rsaKey))
+	segments := strings.Split(valid, ".")
+	forged, _ := json.Marshal(map[string]interface{}{"sub": "admin"})
+
+	tests := []struct {
+		name, token string
+	}{
+		{"unknown kid", signedJWT(t, map[string]interface{}{"alg": "RS256", "kid": "rsa-2"}, claims, signRS256(t, rsaKey))},
+		{"alg none", signedJWT(t, map[string]interface{}{"alg": "none", "kid": "rsa-1"}, claims, func([]byte) []byte { return nil })},
+		{"HS256 with the RSA key", signedJWT(t, map[string]interface{}{"alg": "HS256", "kid": "rsa-1"}, claims, func(input []byte) []byte {
+			mac := hmac.New(sha256.New, []byte(testJWKS(t)[0].N))
+			mac.Write(input)
+			return mac.Sum(nil)
+		})},
+		{"ES256 under the RSA kid", signedJWT(t, map[string]interface{}{"alg": "ES256", "kid": "rsa-1"}, claims, signES256(t, ecKey))},
+		{"tampered claims", segments[0] + "." + base64.RawURLEncoding.EncodeToString(forged) + "." + segments[2]},
+		{"tampered signature", segments[0] + "." + segments[1] + "." + base64.RawURLEncoding.EncodeToString(make([]byte, 256))},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", "/jwt/verify", nil, "Authorization", "Bearer "+tt.token)
+		if rec.status != 401 {
+			t.Errorf("%s: status %d, want 401", tt.name, rec.status)
+			continue
+		}
+		var body struct {
+			Checks map[string]jwtCheck `json:"checks"`
+		}
+		if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if body.Checks["signature"].OK {
+			t.Errorf("%s: signature check passed", tt.name)
+		}
+	}
+}
+
+func TestCheckTimeClaim(t *testing.T) {
+	now := time.Now()
+	before := func(at time.Time) bool { return now.Before(at) }
+	notBefore := func(at time.Time) bool { return !now.Before(at) }
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+		claim  string
+		ok     func(time.Time) bool
+		pass   bool
+	}{
+		{"unexpired", map[string]interface{}{"exp": float64(now.Add(time.Hour).Unix())}, "exp", before, true},
+		{"expired", map[string]interface{}{"exp": float64(now.Add(-time.Hour).Unix())}, "exp", before, false},
+		{"valid already", map[string]interface{}{"nbf": float64(now.Add(-time.Hour).Unix())}, "nbf", notBefore, true},
+		{"not yet valid", map[string]interface{}{"nbf": float64(now.Add(time.Hour).Unix())}, "nbf", notBefore, false},
+		{"missing", map[string]interface{}{}, "exp", before, true},
+		{"not a number", map[string]interface{}{"exp": "tomorrow"}, "exp", before, false},
+	}
+	for _, tt := range tests {
+		err := checkTimeClaim(&jwt{Claims: tt.claims}, tt.claim, tt.ok)
+		if (err == nil) != tt.pass {
+			t.Errorf("%s: err %v, want pass %v", tt.name, err, tt.pass)
+		}
+	}
+}
+
+func TestCheckIssuerAudience(t *testing.T) {
+	tok := &jwt{Claims: map[string]interface{}{"iss": "https://idp.test/", "aud": "edgehttpbin"}}
+	if err := checkIssuer(tok, "https://idp.test/"); err != nil {
+		t.Errorf("matching iss: %v", err)
+	}
+	if err := checkIssuer(tok, "https://evil.test/"); err == nil {
+		t.Error("mismatched iss passed")
+	}
+	if err := checkAudience(tok, "edgehttpbin"); err != nil {
+		t.Errorf("matching aud: %v", err)
+	}
+	if err := checkAudience(tok, "other"); err == nil {
+		t.Error("mismatched aud passed")
+	}
+	list := &jwt{Claims: map[string]interface{}{"aud": []interface{}{"a", "b"}}}
+	if checkAudience(list, "b") != nil || checkAudience(list, "c") == nil {
+		t.Error("aud array not matched by membership")
+	}
+
+	// Through the handler, a mismatch fails the request.
+	fakeJWKS(t, map[string]string{"jwt_issuer": "https://idp.test/", "jwt_audience": "edgehttpbin"})
+	rsaKey, _ := testKeys(t)
+	token := signedJWT(t, map[string]interface{}{"alg": "RS256", "kid": "rsa-1"},
+		map[string]interface{}{"iss": "https://evil.test/", "aud": "edgehttpbin"}, signRS256(t, rsaKey))
+	if rec := serve(t, "GET", "/jwt/verify", nil, "Authorization", "Bearer "+token); rec.status != 401 {
+		t.Errorf("wrong issuer: status %d, want 401", rec.status)
+	}
+}
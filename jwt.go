@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// jwt is a decoded, unverified JSON Web Token.
+type jwt struct {
+	Header    map[string]interface{}
+	Claims    map[string]interface{}
+	Signature []byte
+}
+
+// decodeJWT checks token is three base64url segments whose first two decode
+// to JSON objects. The signature is decoded but not verified.
+func decodeJWT(token string) (*jwt, error) {
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 {
+		return nil, errors.New("token must have three segments")
+	}
+
+	var t jwt
+	for i, dst := range []*map[string]interface{}{&t.Header, &t.Claims} {
+		data, err := base64.RawURLEncoding.DecodeString(segments[i])
+		if err != nil {
+			return nil, errors.New("malformed token segment")
+		}
+		if err := json.Unmarshal(data, dst); err != nil || *dst == nil {
+			return nil, errors.New("token segment isn't a JSON object")
+		}
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return nil, errors.New("malformed token signature")
+	}
+	t.Signature = sig
+	return &t, nil
+}
+
+// expired reports whether the exp claim, if present, is in the past.
+func (t *jwt) expired(now time.Time) (bool, error) {
+	exp, ok := t.Claims["exp"]
+	if !ok {
+		return false, nil
+	}
+	secs, ok := exp.(float64)
+	if !ok {
+		return false, errors.New("exp claim must be a number")
+	}
+	return now.After(time.Unix(int64(secs), 0)), nil
+}
+
+// bearerToken returns the token from an Authorization: Bearer header.
+func bearerToken(r *fsthttp.Request) (string, bool) {
+	tokenFields := strings.Fields(r.Header.Get("Authorization"))
+	if len(tokenFields) != 2 || tokenFields[0] != "Bearer" {
+		return "", false
+	}
+	return tokenFields[1], true
+}
+
+// handleBearerJWT is a stricter /bearer that requires a well formed and
+// unexpired JWT.
+func handleBearerJWT(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	token, ok := bearerToken(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		w.WriteHeader(fsthttp.StatusUnauthorized)
+		return
+	}
+
+	t, err := decodeJWT(token)
+	if err == nil {
+		var expired bool
+		expired, err = t.expired(time.Now())
+		if err == nil && expired {
+			err = errors.New("token has expired")
+		}
+	}
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="`+err.Error()+`"`)
+		fsthttp.Error(w, err.Error(), fsthttp.StatusUnauthorized)
+		return
+	}
+
+	writeJSON(w, r, fsthttp.StatusOK, map[string]interface{}{
+		"authenticated": true,
+		"header":        t.Header,
+		"claims":        t.Claims,
+	})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// unsignedJWT builds a token with claims and a dummy signature.
+func unsignedJWT(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte("sig"))
+}
+
+func TestBearerJWT(t *testing.T) {
+	valid := unsignedJWT(t, map[string]interface{}{"sub": "user", "exp": time.Now().Add(time.Hour).Unix()})
+	rec := serve(t, "GET", "/bearer/jwt", nil, "Authorization", "Bearer "+valid)
+	if rec.status != 200 {
+		t.Fatalf("valid token: status %d, want 200", rec.status)
+	}
+	var body struct {
+		Claims map[string]interface{} `json:"claims"`
+	}
+	if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Claims["sub"] != "user" {
+		t.Errorf("claims %v", body.Claims)
+	}
+
+	tests := []struct {
+		name, token string
+	}{
+		{"expired", unsignedJWT(t, map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})},
+		{"two segments", "abc.def"},
+		{"not JSON", "bm90.anNvbg.c2ln"},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", "/bearer/jwt", nil, "Authorization", "Bearer "+tt.token)
+		if rec.status != 401 {
+			t.Errorf("%s: status %d, want 401", tt.name, rec.status)
+		}
+	}
+}
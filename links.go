@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+func handleLinks(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) != 3 && len(parts) != 4 {
+		fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
+		return
+	}
+
+	n, err := strconv.Atoi(parts[2])
+	if err != nil || n < 1 || n > 200 {
+		fsthttp.Error(w, "Invalid n, must be between 1 and 200", fsthttp.StatusBadRequest)
+		return
+	}
+
+	offset := -1
+	if len(parts) == 4 {
+		offset, err = strconv.Atoi(parts[3])
+		if err != nil || offset < 0 || offset >= n {
+			fsthttp.Error(w, "Invalid offset", fsthttp.StatusBadRequest)
+			return
+		}
+	}
+
+	// Links are root relative by default. ?absolute=true makes them fully
+	// qualified, and ?base=true makes them document relative with a <base>
+	// to resolve them against. The query is kept so the mode carries over.
+	query := r.URL.Query()
+	absolute, _ := strconv.ParseBool(query.Get("absolute"))
+	base, _ := strconv.ParseBool(query.Get("base"))
+	prefix, head := "/", ""
+	switch {
+	case absolute:
+		prefix = requestOrigin(r) + "/"
+	case base:
+		prefix, head = "", fmt.Sprintf(`<base href="%s/">`, html.EscapeString(requestOrigin(r)))
+	}
+	suffix := ""
+	if r.URL.RawQuery != "" {
+		suffix = "?" + r.URL.RawQuery
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<html><head><title>Links</title>%s</head><body>", head)
+	for i := 0; i < n; i++ {
+		if i == offset {
+			fmt.Fprintf(&b, "%d ", i)
+			continue
+		}
+		fmt.Fprintf(&b, `<a href="%s">%d</a> `, html.EscapeString(fmt.Sprintf("%slinks/%d/%d%s", prefix, n, i, suffix)), i)
+	}
+	b.WriteString("</body></html>")
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLinks(t *testing.T) {
+	tests := []struct {
+		target string
+		links  int
+	}{
+		{"/links/10", 10},
+		{"/links/10/3", 9},
+		{"/links/1/0", 0},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", tt.target, nil)
+		if rec.status != 200 {
+			t.Fatalf("GET %s: status %d, want 200", tt.target, rec.status)
+		}
+		if got := strings.Count(rec.body.String(), "<a "); got != tt.links {
+			t.Errorf("GET %s: %d links, want %d", tt.target, got, tt.links)
+		}
+	}
+
+	for _, target := range []string{"/links/0", "/links/201", "/links/10/10"} {
+		if rec := serve(t, "GET", target, nil); rec.status != 400 {
+			t.Errorf("GET %s: status %d, want 400", target, rec.status)
+		}
+	}
+}
+
+func TestLinksMode(t *testing.T) {
+	tests := []struct {
+		target, href, base string
+	}{
+		{"https://edgehttpbin.test/links/3/0", `href="/links/3/1"`, ""},
+		{"https://edgehttpbin.test/links/3/0?absolute=true", `href="https://edgehttpbin.test/links/3/1?absolute=true"`, ""},
+		{"https://edgehttpbin.test/links/3/0?base=true", `href="links/3/1?base=true"`, `<base href="https://edgehttpbin.test/">`},
+	}
+	for _, tt := range tests {
+		body := serve(t, "GET", tt.target, nil).body.String()
+		if !strings.Contains(body, tt.href) {
+			t.Errorf("GET %s: no %s in %s", tt.target, tt.href, body)
+		}
+		if strings.Contains(body, "<base") != (tt.base != "") || !strings.Contains(body, tt.base) {
+			t.Errorf("GET %s: want base %q in %s", tt.target, tt.base, body)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+	"github.com/fastly/compute-sdk-go/rtlog"
+)
+
+// openLogEndpoint returns the writer request logs go to, or nil when the
+// log_endpoint config key isn't set and logging is disabled.
+var openLogEndpoint = func() io.Writer {
+	name, ok := configValue("log_endpoint")
+	if !ok {
+		return nil
+	}
+	return rtlog.Open(name)
+}
+
+type logLine struct {
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	Status     int     `json:"status"`
+	Bytes      int     `json:"bytes"`
+	DurationMS float64 `json:"duration_ms"`
+	ClientIP   string  `json:"client_ip"`
+	RequestID  string  `json:"request_id"`
+}
+
+// loggingResponseWriter records the status and body size of a response.
+type loggingResponseWriter struct {
+	fsthttp.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (lw *loggingResponseWriter) WriteHeader(code int) {
+	if lw.status == 0 {
+		lw.status = code
+	}
+	lw.ResponseWriter.WriteHeader(code)
+}
+
+func (lw *loggingResponseWriter) Write(p []byte) (int, error) {
+	if lw.status == 0 {
+		lw.status = fsthttp.StatusOK
+	}
+	n, err := lw.ResponseWriter.Write(p)
+	lw.bytes += n
+	return n, err
+}
+
+// withLogging wraps h so that every request writes one JSON log line to the
+// configured log endpoint.
+func withLogging(h fsthttp.HandlerFunc) fsthttp.HandlerFunc {
+	return func(ctx context.Context, w fsthttp.ResponseWriter, r *fsthttp.Request) {
+		endpoint := openLogEndpoint()
+		if endpoint == nil {
+			h(ctx, w, r)
+			return
+		}
+
+		start := time.Now()
+		lw := &loggingResponseWriter{ResponseWriter: w}
+		h(ctx, lw, r)
+
+		status := lw.status
+		if status == 0 {
+			status = fsthttp.StatusOK
+		}
+		line, err := json.Marshal(logLine{
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Status:     status,
+			Bytes:      lw.bytes,
+			DurationMS: float64(time.Since(start)) / float64(time.Millisecond),
+			ClientIP:   clientIP(r),
+			RequestID:  lw.Header().Get("X-Request-Id"),
+		})
+		if err != nil {
+			return
+		}
+		endpoint.Write(line)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+func TestLogging(t *testing.T) {
+	var logged bytes.Buffer
+	saved := openLogEndpoint
+	t.Cleanup(func() { openLogEndpoint = saved })
+	openLogEndpoint = func() io.Writer { return &logged }
+
+	req, _ := fsthttp.NewRequest("GET", "/status/404", nil)
+	req.RemoteAddr = "192.0.2.1:4321"
+	rec := newResponseRecorder()
+	withLogging(handler)(context.Background(), rec, req)
+
+	var line logLine
+	if err := json.Unmarshal(logged.Bytes(), &line); err != nil {
+		t.Fatalf("log line %q: %v", logged.String(), err)
+	}
+	if line.Method != "GET" || line.Path != "/status/404" || line.Status != 404 || line.ClientIP != "192.0.2.1" {
+		t.Errorf("log line %+v", line)
+	}
+	if line.Bytes != rec.body.Len() {
+		t.Errorf("bytes %d, want %d", line.Bytes, rec.body.Len())
+	}
+	if line.RequestID == "" || line.RequestID != rec.header.Get("X-Request-Id") {
+		t.Errorf("request_id %q, want %q", line.RequestID, rec.header.Get("X-Request-Id"))
+	}
+	if line.DurationMS < 0 {
+		t.Errorf("duration_ms %v", line.DurationMS)
+	}
+}
+
+func TestLoggingDisabled(t *testing.T) {
+	saved := openLogEndpoint
+	t.Cleanup(func() { openLogEndpoint = saved })
+	openLogEndpoint = func() io.Writer { return nil }
+
+	req, _ := fsthttp.NewRequest("GET", "/status/404", nil)
+	rec := newResponseRecorder()
+	withLogging(handler)(context.Background(), rec, req)
+	if rec.status != 404 {
+		t.Errorf("status %d, want 404", rec.status)
+	}
+}
@@ -4,307 +4,474 @@ import (
 	"context"
 	"crypto/sha1"
 	"embed"
+	"encoding/json"
 	"fmt"
-	"io"
+	"io/fs"
+	"math"
 	"math/rand"
 	"net/http"
 	"path"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fastly/compute-sdk-go/fsthttp"
 )
 
 //go:embed static/*
-var staticAssets embed.FS
+var embeddedAssets embed.FS
 
-var (
-	statusRx   = regexp.MustCompile("/status/([^/]*)")
-	delayRx    = regexp.MustCompile("/delay/([^/]*)")
-	bytesRx    = regexp.MustCompile("/bytes/([^/]*)")
-	redirectRx = regexp.MustCompile("/redirect/([^/]*)")
-	cacheRx    = regexp.MustCompile("/cache/([^/]*)")
-)
+// staticAssets holds the files under static/. It's a variable so that a
+// different file system can stand in for the embedded one.
+var staticAssets fs.ReadFileFS = embeddedAssets
 
 func main() {
 	rand.Seed(time.Now().Unix())
-	fsthttp.ServeFunc(func(ctx context.Context, w fsthttp.ResponseWriter, r *fsthttp.Request) {
-		// Status
-		m := statusRx.FindAllStringSubmatch(r.URL.Path, -1)
-		if m != nil {
-			parts := strings.Split(r.URL.Path, "/")
-			if len(parts) != 3 {
-				fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
-				return
-			}
-			codes := strings.Split(parts[2], ",")
-			if len(codes) > 1 {
-				// Don't cache for random responses
-				w.Header().Add("Surrogate-Control", "max-age=31557600")
-				w.Header().Add("Cache-Control", "no-store, max-age=0")
-			}
-			code, err := strconv.Atoi(codes[rand.Intn(len(codes))])
-			if err != nil {
-				fsthttp.Error(w, "Invalid status", fsthttp.StatusBadRequest)
-				return
-			}
-			if code >= 300 {
-				fsthttp.Error(w, fsthttp.StatusText(code), code)
-				return
-			}
-			if code > 999 {
-				fsthttp.Error(w, fsthttp.StatusText(400), 400)
-			}
-			w.WriteHeader(code)
-
-			return
-		}
+	fsthttp.ServeFunc(withLogging(handler))
+}
 
-		// Delay
-		m = delayRx.FindAllStringSubmatch(r.URL.Path, -1)
-		if m != nil {
-			parts := strings.Split(r.URL.Path, "/")
-			if len(parts) != 3 {
-				fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
-				return
-			}
+const defaultMaxDelay = time.Minute
 
-			delay, err := parseBoundedDuration(parts[2], 0, time.Minute)
-			if err != nil {
-				fsthttp.Error(w, "Invalid duration", fsthttp.StatusBadRequest)
-				return
-			}
-
-			select {
-			case <-ctx.Done():
-				w.WriteHeader(499) // "Client Closed Request" https://httpstatuses.com/499
-				return
-			case <-time.After(delay):
-				w.Write([]byte("delayed ok"))
-				return
-			}
-		}
+var (
+	maxDelayOnce  sync.Once
+	maxDelayValue time.Duration
+)
 
-		// Bytes
-		m = bytesRx.FindAllStringSubmatch(r.URL.Path, -1)
-		if m != nil {
-			handleBytes(w, r)
-			return
-		}
+// maxDelay returns the longest /delay and the other endpoints that wait
+// will wait for, from the delay_max config key in seconds.
+func maxDelay() time.Duration {
+	maxDelayOnce.Do(func() {
+		maxDelayValue = time.Duration(configInt("delay_max", int(defaultMaxDelay/time.Second))) * time.Second
+	})
+	return maxDelayValue
+}
 
-		// cache
-		if r.URL.Path == "/cache" {
-			if r.Header.Get("If-Modified-Since") != "" || r.Header.Get("If-None-Match") != "" {
-				w.WriteHeader(fsthttp.StatusNotModified)
-				return
-			}
+// delayResponse is the body of /delay: the request, including its body, plus
+// the delay applied, in seconds.
+type delayResponse struct {
+	echoInfo
+	Delay float64 `json:"delay"`
+}
 
-			lastModified := time.Now().Format(time.RFC1123)
-			w.Header().Add("Last-Modified", lastModified)
-			w.Header().Add("ETag", sha1hash(lastModified))
-			w.Write([]byte{})
-		}
+func handleDelay(ctx context.Context, w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) == 4 && parts[3] == "stream" {
+		handleDelayStream(ctx, w, r, parts[2])
+		return
+	}
+	if len(parts) != 3 {
+		fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
+		return
+	}
 
-		m = cacheRx.FindAllStringSubmatch(r.URL.Path, -1)
-		if m != nil {
-			parts := strings.Split(r.URL.Path, "/")
-			if len(parts) != 3 {
-				fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
-				return
-			}
+	query := r.URL.Query()
 
-			seconds, err := strconv.ParseInt(parts[2], 10, 64)
-			if err != nil {
-				fsthttp.Error(w, err.Error(), fsthttp.StatusBadRequest)
-				return
-			}
+	// With on-exceed=timeout a delay over the cap waits the cap and then
+	// fails like an upstream that never answered, rather than being rejected.
+	timeout := false
+	delay, err := parseBoundedDuration(parts[2], 0, maxDelay())
+	if err != nil && delay > maxDelay() && query.Get("on-exceed") == "timeout" {
+		delay, timeout, err = maxDelay(), true, nil
+	}
+	if err != nil {
+		fsthttp.Error(w, "Invalid duration", fsthttp.StatusBadRequest)
+		return
+	}
 
-			w.Header().Add("Cache-Control", fmt.Sprintf("public, max-age=%d", seconds))
-			w.Write([]byte{})
+	if param := query.Get("min"); param != "" {
+		min, err := parseBoundedDuration(param, 0, maxDelay())
+		if err != nil {
+			fsthttp.Error(w, "Invalid min", fsthttp.StatusBadRequest)
 			return
 		}
-
-		// Anything
-		if r.URL.Path == "/anything" {
-			w.Header().Apply(r.Header)
-			io.Copy(w, r.Body)
-			return
+		if delay < min {
+			delay = min
 		}
-
-		// User-agent
-		if r.URL.Path == "/user-agent" {
-			w.Header().Set("Content-Type", "text/json; charset=utf-8")
-			fmt.Fprintf(w, `{"user-agent":"%s"}`, r.Header.Get("user-agent"))
+	}
+	if param := query.Get("jitter"); param != "" {
+		jitter, err := parseBoundedDuration(param, 0, maxDelay())
+		if err != nil {
+			fsthttp.Error(w, "Invalid jitter", fsthttp.StatusBadRequest)
 			return
 		}
-
-		// IP
-		if r.URL.Path == "/ip" {
-			w.Header().Set("Content-Type", "text/json; charset=utf-8")
-			fmt.Fprintf(w, `{"origin":"%s"}`, r.RemoteAddr)
-			return
+		// The jitter never takes the total past the cap.
+		if delay+jitter > maxDelay() {
+			jitter = maxDelay() - delay
+		}
+		if jitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(jitter) + 1))
 		}
+	}
+	if wait, ok := preferWait(r); ok && wait < delay {
+		delay = wait
+		w.Header().Set("Preference-Applied", fmt.Sprintf("wait=%d", wait/time.Second))
+	}
+	w.Header().Set("X-Delay-Applied", strconv.FormatFloat(delay.Seconds(), 'f', -1, 64))
+	w.Header().Add("Server-Timing", fmt.Sprintf("delay;dur=%s", strconv.FormatFloat(float64(delay)/float64(time.Millisecond), 'f', -1, 64)))
+
+	// The body is read before delaying. The SDK can't send interim
+	// responses, so the 100 Continue for an Expect: 100-continue request
+	// comes from the edge itself, and this way the handshake completes
+	// before the delay rather than after it.
+	info, err := newEchoInfo(r)
+	if err != nil {
+		bodyError(w, err)
+		return
+	}
+	info.Method = r.Method
 
-		// Bearer
-		if r.URL.Path == "/bearer" {
-			reqToken := r.Header.Get("Authorization")
-			tokenFields := strings.Fields(reqToken)
-			if len(tokenFields) != 2 || tokenFields[0] != "Bearer" {
-				w.Header().Set("WWW-Authenticate", "Bearer")
-				w.WriteHeader(http.StatusUnauthorized)
-				return
-			}
-			fmt.Fprintf(w, `{"authenticated": true, "token":"%s"}`, tokenFields[1])
+	select {
+	case <-ctx.Done():
+		w.WriteHeader(499) // "Client Closed Request" https://httpstatuses.com/499
+		return
+	case <-time.After(delay):
+		if timeout {
+			fsthttp.Error(w, fsthttp.StatusText(fsthttp.StatusGatewayTimeout), fsthttp.StatusGatewayTimeout)
 			return
 		}
+		writeJSON(w, r, fsthttp.StatusOK, delayResponse{info, delay.Seconds()})
+		return
+	}
+}
 
-		// redirect
-		m = redirectRx.FindAllStringSubmatch(r.URL.Path, -1)
-		if m != nil {
-			parts := strings.Split(r.URL.Path, "/")
-			if len(parts) != 3 {
-				fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
-				return
-			}
+// handleDelayStream waits before sending anything, then streams the body in
+// small chunks, so time to first byte and total time can be told apart.
+func handleDelayStream(ctx context.Context, w fsthttp.ResponseWriter, r *fsthttp.Request, input string) {
+	delay, err := parseBoundedDuration(input, 0, maxDelay())
+	if err != nil {
+		fsthttp.Error(w, "Invalid duration", fsthttp.StatusBadRequest)
+		return
+	}
+	info, err := newEchoInfo(r)
+	if err != nil {
+		bodyError(w, err)
+		return
+	}
+	info.Method = r.Method
 
-			redirects, err := strconv.Atoi(parts[2])
-			if err != nil {
-				fsthttp.Error(w, "Invalid redirects", fsthttp.StatusBadRequest)
-				return
-			}
-			if redirects == 0 {
-				w.Write([]byte("completed redirects"))
-				return
-			}
-			if redirects > 20 {
-				fsthttp.Error(w, "maximum of 20 redirects allowed", fsthttp.StatusBadRequest)
-				return
-			}
-			w.Header().Set("Location", fmt.Sprintf("/redirect/%d", redirects-1))
-			fsthttp.Error(w, fsthttp.StatusText(302), 302)
-			return
-		}
+	select {
+	case <-ctx.Done():
+		w.WriteHeader(499)
+		return
+	case <-time.After(delay):
+	}
 
-		// Unstable
-		if r.URL.Path == "/unstable" {
-			rate := 0.5
-			rateParam := r.URL.Query().Get("failure-rate")
-			pRate, err := strconv.ParseFloat(rateParam, 64)
-			w.Header().Add("Surrogate-Control", "max-age=31557600")
-			w.Header().Add("Cache-Control", "no-store, max-age=0")
-			if err == nil {
-				if pRate < 1 && pRate > 0 {
-					rate = pRate
-				}
-			}
-			if rand.Float64() > rate {
-				w.Write([]byte{})
-				return
-			}
-			fsthttp.Error(w, fsthttp.StatusText(500), 500)
-			return
-		}
+	body, err := json.Marshal(delayResponse{info, delay.Seconds()})
+	if err != nil {
+		fsthttp.Error(w, err.Error(), fsthttp.StatusInternalServerError)
+		return
+	}
 
-		// Index
-		if r.URL.Path == "/" {
-			w.Header().Set("Content-Type", "text/html; charset=utf-8")
-			data, err := staticAssets.ReadFile(path.Join("static", "index.html"))
-			if err != nil {
-				fsthttp.Error(w, fsthttp.StatusText(500), 500)
-			}
-			w.Write(data)
-			return
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(fsthttp.StatusOK)
+
+	const chunkSize = 32
+	for len(body) > 0 {
+		n := chunkSize
+		if n > len(body) {
+			n = len(body)
 		}
-		data, err := staticAssets.ReadFile(path.Join("static", strings.TrimLeft(r.URL.Path, "/")))
-		if err == nil {
-			w.Write(data)
+		w.Write(body[:n])
+		body = body[n:]
+
+		select {
+		case <-ctx.Done():
 			return
+		case <-time.After(10 * time.Millisecond):
 		}
-
-		// Catch all other requests and return a 404.
-		fsthttp.Error(w, fsthttp.StatusText(404), 404)
-	})
+	}
 }
 
-func parseDuration(input string) (time.Duration, error) {
-	d, err := time.ParseDuration(input)
-	if err != nil {
-		n, err := strconv.ParseFloat(input, 64)
-		if err != nil {
-			return 0, err
-		}
-		d = time.Duration(n*1000) * time.Millisecond
+func handleCache(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	if r.Header.Get("If-Modified-Since") != "" || r.Header.Get("If-None-Match") != "" {
+		w.WriteHeader(fsthttp.StatusNotModified)
+		return
 	}
-	return d, nil
+
+	lastModified := time.Now().Format(time.RFC1123)
+	w.Header().Add("Last-Modified", lastModified)
+	w.Header().Add("ETag", hexHash(sha1.New, lastModified))
+	w.Write([]byte{})
 }
 
-func parseBoundedDuration(input string, min, max time.Duration) (time.Duration, error) {
-	d, err := parseDuration(input)
-	if err != nil {
-		return 0, err
+// surrogateKeyRx matches the keys /cache/purgeable accepts: a single token
+// that's safe to put in a Surrogate-Key header.
+var surrogateKeyRx = regexp.MustCompile(`^[A-Za-z0-9._~-]{1,256}$`)
+
+// handleCachePurgeable returns a response Fastly will cache for a long time
+// under the given surrogate key, so key based purging can be exercised.
+func handleCachePurgeable(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/cache/purgeable/")
+	if !surrogateKeyRx.MatchString(key) {
+		fsthttp.Error(w, "Invalid key", fsthttp.StatusBadRequest)
+		return
 	}
 
-	if d > max {
-		err = fmt.Errorf("duration %s longer than %s", d, max)
-	} else if d < min {
-		err = fmt.Errorf("duration %s shorter than %s", d, min)
+	w.Header().Set("Surrogate-Key", key)
+	w.Header().Set("Surrogate-Control", "max-age=31557600")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	writeJSON(w, r, fsthttp.StatusOK, map[string]string{
+		"surrogate_key": key,
+		"cached_at":     time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// greetings are the languages /cache/vary can answer in.
+var greetings = map[string]string{
+	"de": "Hallo",
+	"en": "Hello",
+	"es": "Hola",
+	"fr": "Bonjour",
+	"ja": "こんにちは",
+}
+
+// handleCacheVary returns a cacheable response whose body depends on
+// the first language in Accept-Language it knows, so caches can be checked
+// for keeping a copy per language.
+func handleCacheVary(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	lang := "en"
+	for _, tag := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag = strings.ToLower(strings.TrimSpace(strings.SplitN(tag, ";", 2)[0]))
+		tag = strings.SplitN(tag, "-", 2)[0]
+		if _, ok := greetings[tag]; ok {
+			lang = tag
+			break
+		}
 	}
-	return d, err
+
+	w.Header().Set("Vary", "Accept-Language")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	w.Header().Set("Content-Language", lang)
+	writeJSON(w, r, fsthttp.StatusOK, map[string]string{"language": lang, "greeting": greetings[lang]})
 }
 
-func handleBytes(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+// maxCacheSeconds caps /cache/{n} at a year, as RFC 2616 asks that Expires
+// is never further in the future than that.
+const maxCacheSeconds = 365 * 24 * 60 * 60
+
+func handleCacheFor(w fsthttp.ResponseWriter, r *fsthttp.Request) {
 	parts := strings.Split(r.URL.Path, "/")
 	if len(parts) != 3 {
 		fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
 		return
 	}
 
-	numBytes, err := strconv.Atoi(parts[2])
+	seconds, err := strconv.ParseInt(parts[2], 10, 64)
 	if err != nil {
 		fsthttp.Error(w, err.Error(), fsthttp.StatusBadRequest)
 		return
 	}
+	if seconds < 0 {
+		fsthttp.Error(w, "Invalid n, must not be negative", fsthttp.StatusBadRequest)
+		return
+	}
+	if seconds > maxCacheSeconds {
+		seconds = maxCacheSeconds
+	}
+
+	w.Header().Add("Cache-Control", fmt.Sprintf("public, max-age=%d", seconds))
+	w.Header().Add("Expires", time.Now().Add(time.Duration(seconds)*time.Second).UTC().Format(http.TimeFormat))
+	w.Write([]byte{})
+}
 
-	if numBytes < 0 {
-		fsthttp.Error(w, "Bad Request", fsthttp.StatusBadRequest)
+// handleAnything describes any request made to /anything or below it, with
+// its method, body and headers. The description is compressed when the
+// client accepts it, with the size before compression kept in a header so it
+// can still be checked.
+func handleAnything(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	info, err := newEchoInfo(r)
+	if err != nil {
+		bodyError(w, err)
 		return
 	}
+	info.Method = r.Method
 
-	// Special case 0 bytes and exit early, since streaming & chunk size do not
-	// matter here.
-	if numBytes == 0 {
-		w.Header().Set("Content-Length", "0")
-		w.WriteHeader(fsthttp.StatusOK)
+	w.Header().Add("Vary", "Accept-Encoding")
+	coding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+	if coding == "" {
+		writeJSON(w, r, fsthttp.StatusOK, info)
+		return
+	}
+	data, err := encodeJSON(r, info)
+	if err != nil {
+		fsthttp.Error(w, err.Error(), fsthttp.StatusInternalServerError)
+		return
+	}
+	compressed, err := compress(coding, data)
+	if err != nil {
+		fsthttp.Error(w, err.Error(), fsthttp.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Encoding", coding)
+	w.Header().Set("X-Uncompressed-Length", strconv.Itoa(len(data)))
+	if wantChunked(r) {
+		writeChunked(w, fsthttp.StatusOK, compressed)
 		return
 	}
+	w.Write(compressed)
+}
+
+func handleUserAgent(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	writeJSON(w, r, fsthttp.StatusOK, map[string]string{"user-agent": r.Header.Get("User-Agent")})
+}
 
-	if numBytes > 100*1024 {
-		numBytes = 100 * 1024
+// handleBearer accepts any Bearer token, or only those listed one per line
+// in the bearer_tokens secret when it is set. A token that is a JWT is
+// decoded, without checking its signature, and its header, claims and expiry
+// are returned along with it.
+func handleBearer(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	token, ok := bearerToken(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if tokens, ok := secretValue("bearer_tokens"); ok && !secretTokenMatches(tokens, token) {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
 	}
+	body := map[string]interface{}{"authenticated": true, "token": token}
+	if t, err := decodeJWT(token); err == nil {
+		info := map[string]interface{}{"header": t.Header, "claims": t.Claims}
+		if expired, err := t.expired(time.Now()); err == nil {
+			info["expired"] = expired
+		}
+		body["jwt"] = info
+	}
+	writeJSON(w, r, fsthttp.StatusOK, body)
+}
 
-	writer := func(chunk []byte) {
-		w.Header().Set("Content-Length", strconv.Itoa(len(chunk)))
-		w.Write(chunk)
+// unstableTTL is how long an /unstable?n= counter lives after its last
+// request, so an idle counter starts over rather than being kept forever.
+const unstableTTL = time.Hour
+
+func handleUnstable(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	w.Header().Add("Surrogate-Control", "max-age=31557600")
+	w.Header().Add("Cache-Control", "no-store, max-age=0")
+
+	// Every nth request fails, for tests that need to be deterministic.
+	if nParam := r.URL.Query().Get("n"); nParam != "" {
+		n, err := strconv.ParseInt(nParam, 10, 64)
+		if err != nil || n <= 0 {
+			fsthttp.Error(w, "Invalid n, must be a positive integer", fsthttp.StatusBadRequest)
+			return
+		}
+		count, err := incrementCounter("unstable/"+nParam, unstableTTL)
+		if err != nil {
+			fsthttp.Error(w, err.Error(), fsthttp.StatusInternalServerError)
+			return
+		}
+		if count%n != 0 {
+			w.Write([]byte{})
+			return
+		}
+		fsthttp.Error(w, fsthttp.StatusText(500), 500)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/octet-stream")
+	rate := 0.5
+	rateParam := r.URL.Query().Get("failure-rate")
+	pRate, err := strconv.ParseFloat(rateParam, 64)
+	if err == nil {
+		if pRate < 1 && pRate > 0 {
+			rate = pRate
+		}
+	}
+	if rand.Float64() > rate {
+		w.Write([]byte{})
+		return
+	}
+	fsthttp.Error(w, fsthttp.StatusText(500), 500)
+}
+
+func handleIndex(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	serveStatic(w, "index.html", "text/html; charset=utf-8")
+}
+
+func handleDeny(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	serveStatic(w, "deny.txt", "text/plain; charset=utf-8")
+}
+
+func handleFormsPost(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	serveStatic(w, "forms-post.html", "text/html; charset=utf-8")
+}
 
-	var chunk []byte
-	for i := 0; i < numBytes; i++ {
-		chunk = append(chunk, byte(rand.Intn(256)))
-		if len(chunk) == numBytes {
-			writer(chunk)
-			chunk = nil
+func handleEncodingUTF8(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	serveStatic(w, "utf8.html", "text/html; charset=utf-8")
+}
+
+// serveStatic writes an embedded static file with an explicit Content-Type.
+func serveStatic(w fsthttp.ResponseWriter, name, contentType string) {
+	data, err := staticAssets.ReadFile(path.Join("static", name))
+	if err != nil {
+		fsthttp.Error(w, fsthttp.StatusText(500), 500)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(data)
+}
+
+// parseDuration parses a Go duration such as "500ms", or a bare number of
+// seconds such as "1.5". Negative durations are rejected, as are NaN, Inf and
+// numbers of seconds too large to fit in a time.Duration.
+func parseDuration(input string) (time.Duration, error) {
+	d, err := time.ParseDuration(input)
+	if err != nil {
+		n, err := strconv.ParseFloat(input, 64)
+		if err != nil {
+			return 0, err
+		}
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return 0, fmt.Errorf("invalid duration %q", input)
+		}
+		if n > float64(math.MaxInt64/int64(time.Second)) {
+			return 0, fmt.Errorf("duration %q out of range", input)
 		}
+		d = time.Duration(n*1000) * time.Millisecond
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative duration %q", input)
 	}
-	if len(chunk) > 0 {
-		writer(chunk)
+	return d, nil
+}
+
+// preferWait returns the longest the client is willing to wait, from a
+// Prefer: wait=N header as described in RFC 7240 section 4.3.
+func preferWait(r *fsthttp.Request) (time.Duration, bool) {
+	for _, header := range r.Header.Values("Prefer") {
+		for _, pref := range strings.Split(header, ",") {
+			pref = strings.TrimSpace(strings.SplitN(pref, ";", 2)[0])
+			kv := strings.SplitN(pref, "=", 2)
+			if len(kv) != 2 || !strings.EqualFold(strings.TrimSpace(kv[0]), "wait") {
+				continue
+			}
+			n, err := strconv.ParseInt(strings.Trim(strings.TrimSpace(kv[1]), `"`), 10, 64)
+			if err != nil || n < 0 {
+				continue
+			}
+			if n > int64(maxDelay()/time.Second) {
+				return maxDelay(), true
+			}
+			return time.Duration(n) * time.Second, true
+		}
 	}
+	return 0, false
 }
 
-func sha1hash(input string) string {
-	h := sha1.New()
-	return fmt.Sprintf("%x", h.Sum([]byte(input)))
+func parseBoundedDuration(input string, min, max time.Duration) (time.Duration, error) {
+	d, err := parseDuration(input)
+	if err != nil {
+		return 0, err
+	}
+
+	if d > max {
+		err = fmt.Errorf("duration %s longer than %s", d, max)
+	} else if d < min {
+		err = fmt.Errorf("duration %s shorter than %s", d, min)
+	}
+	return d, err
 }
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// serve runs a request through the dispatcher and returns what it wrote.
+// Headers are given as name, value pairs.
+func serve(t *testing.T, method, target string, body io.Reader, header ...string) *responseRecorder {
+	t.Helper()
+	req, err := fsthttp.NewRequest(method, target, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Add(header[i], header[i+1])
+	}
+	req.RemoteAddr = "192.0.2.1:4321"
+
+	rec := newResponseRecorder()
+	handler(context.Background(), rec, req)
+	return rec
+}
+
+func TestDelayExpectContinue(t *testing.T) {
+	// The edge answers the Expect itself, so all /delay has to do is read
+	// the body and echo it as usual.
+	rec := serve(t, "POST", "/delay/0", strings.NewReader("hello"),
+		"Expect", "100-continue", "Content-Type", "text/plain")
+	if rec.status != 200 {
+		t.Fatalf("status %d, want 200", rec.status)
+	}
+	var body delayResponse
+	if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Data != "hello" {
+		t.Errorf("data = %q, want %q", body.Data, "hello")
+	}
+}
+
+func TestStaticPages(t *testing.T) {
+	tests := []struct {
+		target, contentType, contains string
+	}{
+		{"/deny", "text/plain; charset=utf-8", "YOU SHOULDN'T BE HERE"},
+		{"/encoding/utf8", "text/html; charset=utf-8", "∮ E⋅da = Q"},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", tt.target, nil)
+		if rec.status != 200 {
+			t.Errorf("GET %s: status %d, want 200", tt.target, rec.status)
+		}
+		if got := rec.header.Get("Content-Type"); got != tt.contentType {
+			t.Errorf("GET %s: Content-Type %q, want %q", tt.target, got, tt.contentType)
+		}
+		if !strings.Contains(rec.body.String(), tt.contains) {
+			t.Errorf("GET %s: body doesn't contain %q", tt.target, tt.contains)
+		}
+	}
+}
+
+func TestDelayJitter(t *testing.T) {
+	tests := []struct {
+		target   string
+		min, max float64
+	}{
+		{"/delay/0.1?jitter=0.1", 0.1, 0.2},
+		{"/delay/0?min=0.1", 0.1, 0.1},
+		{"/delay/0.1?min=0.05&jitter=0.05", 0.1, 0.15},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", tt.target, nil)
+		applied, err := strconv.ParseFloat(rec.header.Get("X-Delay-Applied"), 64)
+		if err != nil {
+			t.Fatalf("GET %s: X-Delay-Applied %q", tt.target, rec.header.Get("X-Delay-Applied"))
+		}
+		if applied < tt.min || applied > tt.max {
+			t.Errorf("GET %s: applied %v, want between %v and %v", tt.target, applied, tt.min, tt.max)
+		}
+	}
+
+	if rec := serve(t, "GET", "/delay/0?jitter=-1", nil); rec.status != 400 {
+		t.Errorf("negative jitter: status %d, want 400", rec.status)
+	}
+}
+
+func TestDelayJSON(t *testing.T) {
+	start := time.Now()
+	rec := serve(t, "GET", "/delay/0.2", nil)
+	elapsed := time.Since(start)
+	if rec.status != 200 || rec.header.Get("Content-Type") != "application/json; charset=utf-8" {
+		t.Fatalf("status %d, Content-Type %q", rec.status, rec.header.Get("Content-Type"))
+	}
+	var body delayResponse
+	if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Delay != 0.2 {
+		t.Errorf("delay = %v, want 0.2", body.Delay)
+	}
+	if elapsed < 200*time.Millisecond {
+		t.Errorf("responded after %v", elapsed)
+	}
+}
+
+func TestDelayCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := fsthttp.NewRequest("GET", "/delay/10", nil)
+	rec := newResponseRecorder()
+	handler(ctx, rec, req)
+	if rec.status != 499 {
+		t.Errorf("status %d, want 499", rec.status)
+	}
+}
+
+func TestFormsPost(t *testing.T) {
+	rec := serve(t, "GET", "/forms/post", nil)
+	if got := rec.header.Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type %q", got)
+	}
+	if !strings.Contains(rec.body.String(), `action="/post"`) {
+		t.Error("form doesn't post to /post")
+	}
+}
+
+func TestUnstableEveryNth(t *testing.T) {
+	const n = 7
+	failures := 0
+	for i := 0; i < 3*n; i++ {
+		rec := serve(t, "GET", "/unstable?n="+strconv.Itoa(n), nil)
+		if rec.status == 500 {
+			failures++
+			if (i+1)%n != 0 {
+				t.Errorf("request %d failed", i+1)
+			}
+		}
+	}
+	if failures != 3 {
+		t.Errorf("%d failures, want 3", failures)
+	}
+	if rec := serve(t, "GET", "/unstable?n=0", nil); rec.status != 400 {
+		t.Errorf("n=0: status %d, want 400", rec.status)
+	}
+}
+
+func TestDelayServerTiming(t *testing.T) {
+	rec := serve(t, "GET", "/delay/0.05", nil)
+	if got := rec.header.Get("Server-Timing"); got != "delay;dur=50" {
+		t.Errorf("Server-Timing %q, want delay;dur=50", got)
+	}
+}
+
+func TestCacheFor(t *testing.T) {
+	rec := serve(t, "GET", "/cache/60", nil)
+	if got := rec.header.Get("Cache-Control"); got != "public, max-age=60" {
+		t.Errorf("Cache-Control %q", got)
+	}
+	expires, err := http.ParseTime(rec.header.Get("Expires"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Until(expires); d < 58*time.Second || d > 61*time.Second {
+		t.Errorf("Expires is %v away, want about 60s", d)
+	}
+
+	if rec := serve(t, "GET", "/cache/-1", nil); rec.status != 400 {
+		t.Errorf("negative n: status %d, want 400", rec.status)
+	}
+	rec = serve(t, "GET", "/cache/999999999999", nil)
+	if got := rec.header.Get("Cache-Control"); got != "public, max-age=31536000" {
+		t.Errorf("large n: Cache-Control %q, want a year", got)
+	}
+}
+
+// firstByteRecorder notes when the first byte of the body was written.
+type firstByteRecorder struct {
+	*responseRecorder
+	first time.Time
+}
+
+func (rec *firstByteRecorder) Write(p []byte) (int, error) {
+	if rec.first.IsZero() {
+		rec.first = time.Now()
+	}
+	return rec.responseRecorder.Write(p)
+}
+
+func TestDelayStream(t *testing.T) {
+	req, err := fsthttp.NewRequest("GET", "/delay/0.1/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := &firstByteRecorder{responseRecorder: newResponseRecorder()}
+	start := time.Now()
+	handler(context.Background(), rec, req)
+
+	if rec.status != 200 {
+		t.Fatalf("status %d, want 200", rec.status)
+	}
+	if ttfb := rec.first.Sub(start); ttfb < 100*time.Millisecond {
+		t.Errorf("first byte after %v, want at least 100ms", ttfb)
+	}
+	var body delayResponse
+	if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Delay != 0.1 {
+		t.Errorf("delay = %v, want 0.1", body.Delay)
+	}
+}
+
+func TestCachePurgeable(t *testing.T) {
+	rec := serve(t, "GET", "/cache/purgeable/product-42", nil)
+	if rec.status != 200 {
+		t.Fatalf("status %d, want 200", rec.status)
+	}
+	if got := rec.header.Get("Surrogate-Key"); got != "product-42" {
+		t.Errorf("Surrogate-Key %q, want product-42", got)
+	}
+	if got := rec.header.Get("Surrogate-Control"); got != "max-age=31557600" {
+		t.Errorf("Surrogate-Control %q", got)
+	}
+
+	for _, key := range []string{"two%20keys", "a,b", strings.Repeat("k", 257)} {
+		if rec := serve(t, "GET", "/cache/purgeable/"+key, nil); rec.status != 400 {
+			t.Errorf("key %.20s: status %d, want 400", key, rec.status)
+		}
+	}
+}
+
+func TestDelayOnExceed(t *testing.T) {
+	fakeConfig(t, map[string]string{"delay_max": "1"})
+
+	if rec := serve(t, "GET", "/delay/2", nil); rec.status != 400 {
+		t.Errorf("over the cap: status %d, want 400", rec.status)
+	}
+
+	start := time.Now()
+	rec := serve(t, "GET", "/delay/2?on-exceed=timeout", nil)
+	if rec.status != 504 {
+		t.Errorf("on-exceed=timeout: status %d, want 504", rec.status)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second || elapsed > 1900*time.Millisecond {
+		t.Errorf("on-exceed=timeout answered after %v, want the 1s cap", elapsed)
+	}
+	if got := rec.header.Get("X-Delay-Applied"); got != "1" {
+		t.Errorf("X-Delay-Applied %q, want 1", got)
+	}
+}
+
+func TestAnythingCompressed(t *testing.T) {
+	decoders := map[string]func(io.Reader) (io.ReadCloser, error){
+		"gzip":    func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+		"deflate": zlib.NewReader,
+	}
+	for coding, newReader := range decoders {
+		rec := serve(t, "GET", "/anything/zipped?x=1", nil, "Accept-Encoding", coding)
+		if got := rec.header.Get("Content-Encoding"); got != coding {
+			t.Fatalf("%s: Content-Encoding %q", coding, got)
+		}
+		zr, err := newReader(bytes.NewReader(rec.body.Bytes()))
+		if err != nil {
+			t.Fatalf("%s: %v", coding, err)
+		}
+		data, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("%s: %v", coding, err)
+		}
+		if got := rec.header.Get("X-Uncompressed-Length"); got != strconv.Itoa(len(data)) {
+			t.Errorf("%s: X-Uncompressed-Length %s, decompressed %d bytes", coding, got, len(data))
+		}
+		var body echoInfo
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Fatalf("%s: %v", coding, err)
+		}
+		if body.Method != "GET" || !strings.HasSuffix(body.URL, "/anything/zipped?x=1") {
+			t.Errorf("%s: echoed %s %s", coding, body.Method, body.URL)
+		}
+	}
+
+	rec := serve(t, "GET", "/anything", nil, "Accept-Encoding", "br;q=1, identity")
+	if rec.header.Get("Content-Encoding") != "" || !json.Valid(rec.body.Bytes()) {
+		t.Errorf("unsupported coding: Content-Encoding %q", rec.header.Get("Content-Encoding"))
+	}
+}
+
+func TestDelayPreferWait(t *testing.T) {
+	tests := []struct {
+		target, prefer, applied, delay string
+	}{
+		{"/delay/5", "wait=0", "wait=0", "0"},
+		{"/delay/5", "respond-async, wait=0", "wait=0", "0"},
+		{"/delay/0.05", "wait=5", "", "0.05"},
+		{"/delay/0.05", "wait=abc", "", "0.05"},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", tt.target, nil, "Prefer", tt.prefer)
+		if rec.status != 200 {
+			t.Errorf("Prefer %q: status %d, want 200", tt.prefer, rec.status)
+		}
+		if got := rec.header.Get("Preference-Applied"); got != tt.applied {
+			t.Errorf("Prefer %q: Preference-Applied %q, want %q", tt.prefer, got, tt.applied)
+		}
+		if got := rec.header.Get("X-Delay-Applied"); got != tt.delay {
+			t.Errorf("Prefer %q: X-Delay-Applied %q, want %q", tt.prefer, got, tt.delay)
+		}
+	}
+}
+
+func TestCacheVary(t *testing.T) {
+	tests := []struct {
+		acceptLanguage, lang string
+	}{
+		{"", "en"},
+		{"fr-CA, en;q=0.8", "fr"},
+		{"xx, DE;q=0.5", "de"},
+		{"zz", "en"},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", "/cache/vary", nil, "Accept-Language", tt.acceptLanguage)
+		if got := rec.header.Get("Vary"); got != "Accept-Language" {
+			t.Errorf("Accept-Language %q: Vary %q", tt.acceptLanguage, got)
+		}
+		if got := rec.header.Get("Content-Language"); got != tt.lang {
+			t.Errorf("Accept-Language %q: Content-Language %q, want %q", tt.acceptLanguage, got, tt.lang)
+		}
+		var body map[string]string
+		if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body["language"] != tt.lang || body["greeting"] != greetings[tt.lang] {
+			t.Errorf("Accept-Language %q: body %v", tt.acceptLanguage, body)
+		}
+	}
+}
+
+func TestStaticAssetMissing(t *testing.T) {
+	saved := staticAssets
+	staticAssets = fstest.MapFS{"static/sample.json": {Data: []byte(`{}`)}}
+	t.Cleanup(func() { staticAssets = saved })
+
+	for _, target := range []string{"/", "/image/png", "/html"} {
+		rec := serve(t, "GET", target, nil)
+		if rec.status != 500 {
+			t.Errorf("GET %s: status %d, want 500", target, rec.status)
+		}
+		if got := rec.body.String(); got != "Internal Server Error\n" {
+			t.Errorf("GET %s: body %q, want only the error", target, got)
+		}
+	}
+	if rec := serve(t, "GET", "/json", nil); rec.status != 200 {
+		t.Errorf("GET /json: status %d, want 200 from the stand-in", rec.status)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+		ok    bool
+	}{
+		{"0", 0, true},
+		{"1.5", 1500 * time.Millisecond, true},
+		{"500ms", 500 * time.Millisecond, true},
+		{"2s", 2 * time.Second, true},
+		{"-1", 0, false},
+		{"-1s", 0, false},
+		{"NaN", 0, false},
+		{"Inf", 0, false},
+		{"-Inf", 0, false},
+		{"1e300", 0, false},
+		{"abc", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseDuration(tt.input)
+		if (err == nil) != tt.ok || (tt.ok && got != tt.want) {
+			t.Errorf("parseDuration(%q) = %v, %v, want %v, ok %v", tt.input, got, err, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+const maxMultipartParts = 100
+
+// multipartPart describes one part of a multipart/form-data body.
+type multipartPart struct {
+	Name        string `json:"name"`
+	Filename    string `json:"filename,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size"`
+}
+
+// handleMultipart describes each part of a multipart/form-data body without
+// echoing its content. The body as a whole is bounded by body_max.
+func handleMultipart(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		fsthttp.Error(w, "Request body must be multipart/form-data", fsthttp.StatusUnsupportedMediaType)
+		return
+	}
+
+	data, err := readBody(r)
+	if err != nil {
+		bodyError(w, err)
+		return
+	}
+
+	parts := []multipartPart{}
+	mr := multipart.NewReader(bytes.NewReader(data), params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fsthttp.Error(w, err.Error(), fsthttp.StatusBadRequest)
+			return
+		}
+		if len(parts) == maxMultipartParts {
+			fsthttp.Error(w, fmt.Sprintf("Too many parts, at most %d are allowed", maxMultipartParts), fsthttp.StatusRequestEntityTooLarge)
+			return
+		}
+
+		size, err := io.Copy(io.Discard, p)
+		if err != nil {
+			fsthttp.Error(w, err.Error(), fsthttp.StatusBadRequest)
+			return
+		}
+		parts = append(parts, multipartPart{
+			Name:        p.FormName(),
+			Filename:    p.FileName(),
+			ContentType: p.Header.Get("Content-Type"),
+			Size:        size,
+		})
+	}
+
+	writeJSON(w, r, fsthttp.StatusOK, map[string]interface{}{"parts": parts})
+}
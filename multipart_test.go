@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMultipart(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("greeting", "hello")
+	fw, _ := mw.CreateFormFile("upload", "notes.txt")
+	fw.Write([]byte(strings.Repeat("x", 1000)))
+	mw.Close()
+
+	rec := serve(t, "POST", "/multipart", &body, "Content-Type", mw.FormDataContentType())
+	if rec.status != 200 {
+		t.Fatalf("status %d: %s", rec.status, rec.body.String())
+	}
+	var got struct {
+		Parts []multipartPart `json:"parts"`
+	}
+	if err := json.Unmarshal(rec.body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []multipartPart{
+		{Name: "greeting", Size: 5},
+		{Name: "upload", Filename: "notes.txt", ContentType: "application/octet-stream", Size: 1000},
+	}
+	if !reflect.DeepEqual(got.Parts, want) {
+		t.Errorf("parts %+v, want %+v", got.Parts, want)
+	}
+	if strings.Contains(rec.body.String(), "hello") {
+		t.Error("part content was echoed")
+	}
+}
+
+func TestMultipartInvalid(t *testing.T) {
+	if rec := serve(t, "POST", "/multipart", strings.NewReader("a=b"), "Content-Type", "application/x-www-form-urlencoded"); rec.status != 415 {
+		t.Errorf("form body: status %d, want 415", rec.status)
+	}
+	if rec := serve(t, "POST", "/multipart", strings.NewReader("garbage"), "Content-Type", "multipart/form-data; boundary=xyz"); rec.status != 400 {
+		t.Errorf("malformed body: status %d, want 400", rec.status)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+const (
+	// oauthTokenTTL is how long the tokens issued by /oauth/token last.
+	oauthTokenTTL = 5 * time.Minute
+
+	// oauthTestKey signs tokens when the secret store has no
+	// oauth_signing_key. It's public, so such tokens are only good for tests.
+	oauthTestKey = "edgehttpbin-oauth-test-key"
+)
+
+// oauthSigningKey returns the HMAC key for the tokens of /oauth/token.
+func oauthSigningKey() []byte {
+	if key, ok := secretValue("oauth_signing_key"); ok {
+		return key
+	}
+	return []byte(oauthTestKey)
+}
+
+// oauthError writes an error response as described in RFC 6749 section 5.2.
+func oauthError(w fsthttp.ResponseWriter, r *fsthttp.Request, status int, code, description string) {
+	w.Header().Set("Cache-Control", "no-store")
+	if status == fsthttp.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="edgehttpbin"`)
+	}
+	writeJSON(w, r, status, map[string]string{"error": code, "error_description": description})
+}
+
+// oauthForm reads a form encoded request body, as both OAuth endpoints take.
+func oauthForm(r *fsthttp.Request) (url.Values, error) {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/x-www-form-urlencoded" {
+		return nil, fmt.Errorf("request body must be application/x-www-form-urlencoded")
+	}
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	return url.ParseQuery(string(body))
+}
+
+// handleOAuthToken is a fake authorization server token endpoint. It issues
+// short lived HS256 JWTs for the client_credentials and password grants,
+// accepting any non-empty client and user credentials. Client credentials
+// may be sent with Basic auth or in the form.
+func handleOAuthToken(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	form, err := oauthForm(r)
+	if err != nil {
+		oauthError(w, r, fsthttp.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	clientID, clientSecret, ok := basicCredentials(r)
+	if !ok {
+		clientID, clientSecret = form.Get("client_id"), form.Get("client_secret")
+	}
+	if clientID == "" || clientSecret == "" {
+		oauthError(w, r, fsthttp.StatusUnauthorized, "invalid_client", "client_id and client_secret are required")
+		return
+	}
+
+	now := time.Now()
+	jti := make([]byte, 16)
+	rand.Read(jti)
+	claims := map[string]interface{}{
+		"iss":       requestOrigin(r),
+		"sub":       clientID,
+		"client_id": clientID,
+		"iat":       now.Unix(),
+		"exp":       now.Add(oauthTokenTTL).Unix(),
+		"jti":       fmt.Sprintf("%x", jti),
+	}
+	switch grant := form.Get("grant_type"); grant {
+	case "client_credentials":
+	case "password":
+		username, password := form.Get("username"), form.Get("password")
+		if username == "" || password == "" {
+			oauthError(w, r, fsthttp.StatusBadRequest, "invalid_grant", "username and password are required")
+			return
+		}
+		claims["sub"] = username
+		claims["username"] = username
+	case "":
+		oauthError(w, r, fsthttp.StatusBadRequest, "invalid_request", "grant_type is required")
+		return
+	default:
+		oauthError(w, r, fsthttp.StatusBadRequest, "unsupported_grant_type", fmt.Sprintf("grant_type %s isn't supported", grant))
+		return
+	}
+	if scope := form.Get("scope"); scope != "" {
+		claims["scope"] = scope
+	}
+
+	token, err := signJWT(claims, oauthSigningKey())
+	if err != nil {
+		fsthttp.Error(w, err.Error(), fsthttp.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	resp := map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int(oauthTokenTTL / time.Second),
+	}
+	if scope, ok := claims["scope"]; ok {
+		resp["scope"] = scope
+	}
+	writeJSON(w, r, fsthttp.StatusOK, resp)
+}
+
+// handleOAuthIntrospect reports whether a token issued by /oauth/token is
+// still active, as described in RFC 7662.
+func handleOAuthIntrospect(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	form, err := oauthForm(r)
+	if err != nil {
+		oauthError(w, r, fsthttp.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	token := form.Get("token")
+	if token == "" {
+		oauthError(w, r, fsthttp.StatusBadRequest, "invalid_request", "token is required")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	t, err := decodeJWT(token)
+	if err != nil || !verifyHS256(token, t, oauthSigningKey()) {
+		writeJSON(w, r, fsthttp.StatusOK, map[string]interface{}{"active": false})
+		return
+	}
+	if expired, err := t.expired(time.Now()); err != nil || expired {
+		writeJSON(w, r, fsthttp.StatusOK, map[string]interface{}{"active": false})
+		return
+	}
+
+	resp := map[string]interface{}{"active": true, "token_type": "Bearer"}
+	for key, value := range t.Claims {
+		resp[key] = value
+	}
+	writeJSON(w, r, fsthttp.StatusOK, resp)
+}
+
+// signJWT returns claims as a JWT signed with HS256.
+func signJWT(claims map[string]interface{}, key []byte) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// verifyHS256 reports whether t, decoded from token, is signed with HS256
+// and key.
+func verifyHS256(token string, t *jwt, key []byte) bool {
+	if alg, _ := t.Header["alg"].(string); alg != "HS256" {
+		return false
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(token[:strings.LastIndexByte(token, '.')]))
+	return hmac.Equal(mac.Sum(nil), t.Signature)
+}
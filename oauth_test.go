@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// oauthPost posts form to an /oauth endpoint and decodes the JSON response.
+func oauthPost(t *testing.T, target string, form url.Values, header ...string) (int, map[string]interface{}) {
+	t.Helper()
+	header = append([]string{"Content-Type", "application/x-www-form-urlencoded"}, header...)
+	rec := serve(t, "POST", target, strings.NewReader(form.Encode()), header...)
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+		t.Fatalf("POST %s: %v: %s", target, err, rec.body.String())
+	}
+	return rec.status, body
+}
+
+// oauthToken issues a token for form and fails the test if it isn't issued.
+func oauthToken(t *testing.T, form url.Values, header ...string) map[string]interface{} {
+	t.Helper()
+	status, body := oauthPost(t, "/oauth/token", form, header...)
+	if status != 200 {
+		t.Fatalf("POST /oauth/token %s: status %d, want 200: %v", form.Encode(), status, body)
+	}
+	return body
+}
+
+func introspect(t *testing.T, token string) map[string]interface{} {
+	t.Helper()
+	status, body := oauthPost(t, "/oauth/introspect", url.Values{"token": {token}})
+	if status != 200 {
+		t.Fatalf("POST /oauth/introspect: status %d, want 200", status)
+	}
+	return body
+}
+
+func TestOAuthToken(t *testing.T) {
+	client := oauthToken(t, url.Values{"grant_type": {"client_credentials"}, "scope": {"read"}},
+		"Authorization", basicAuthorization("app:secret"))
+	if client["token_type"] != "Bearer" || client["scope"] != "read" || client["expires_in"] != float64(300) {
+		t.Errorf("client_credentials: got %v", client)
+	}
+	claims := introspect(t, client["access_token"].(string))
+	if claims["active"] != true || claims["sub"] != "app" || claims["client_id"] != "app" {
+		t.Errorf("client_credentials introspection: got %v", claims)
+	}
+
+	password := oauthToken(t, url.Values{
+		"grant_type": {"password"}, "client_id": {"app"}, "client_secret": {"secret"},
+		"username": {"alice"}, "password": {"hunter2"},
+	})
+	claims = introspect(t, password["access_token"].(string))
+	if claims["active"] != true || claims["sub"] != "alice" || claims["username"] != "alice" {
+		t.Errorf("password introspection: got %v", claims)
+	}
+}
+
+func TestOAuthTokenErrors(t *testing.T) {
+	client := url.Values{"client_id": {"app"}, "client_secret": {"secret"}}
+	with := func(extra url.Values) url.Values {
+		form := url.Values{}
+		for k, v := range client {
+			form[k] = v
+		}
+		for k, v := range extra {
+			form[k] = v
+		}
+		return form
+	}
+	tests := []struct {
+		name   string
+		form   url.Values
+		status int
+		code   string
+	}{
+		{"unsupported grant", with(url.Values{"grant_type": {"authorization_code"}}), 400, "unsupported_grant_type"},
+		{"missing grant", with(nil), 400, "invalid_request"},
+		{"missing client", url.Values{"grant_type": {"client_credentials"}}, 401, "invalid_client"},
+		{"missing password", with(url.Values{"grant_type": {"password"}, "username": {"alice"}}), 400, "invalid_grant"},
+	}
+	for _, tt := range tests {
+		status, body := oauthPost(t, "/oauth/token", tt.form)
+		if status != tt.status || body["error"] != tt.code {
+			t.Errorf("%s: status %d error %v, want %d %s", tt.name, status, body["error"], tt.status, tt.code)
+		}
+	}
+
+	rec := serve(t, "POST", "/oauth/token", strings.NewReader(with(url.Values{"grant_type": {"client_credentials"}}).Encode()),
+		"Content-Type", "application/json")
+	if rec.status != 400 || !strings.Contains(rec.body.String(), `"invalid_request"`) {
+		t.Errorf("JSON body: status %d, want 400 invalid_request: %s", rec.status, rec.body.String())
+	}
+	if status, body := oauthPost(t, "/oauth/introspect", url.Values{}); status != 400 || body["error"] != "invalid_request" {
+		t.Errorf("introspect without token: status %d error %v, want 400 invalid_request", status, body["error"])
+	}
+}
+
+func TestOAuthIntrospectInactive(t *testing.T) {
+	issued := oauthToken(t, url.Values{"grant_type": {"client_credentials"}}, "Authorization", basicAuthorization("app:secret"))
+	token := issued["access_token"].(string)
+	segments := strings.Split(token, ".")
+	forged, err := signJWT(map[string]interface{}{"sub": "admin"}, []byte(oauthTestKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := signJWT(map[string]interface{}{"sub": "app", "exp": time.Now().Add(-time.Minute).Unix()}, []byte(oauthTestKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"tampered claims":    segments[0] + "." + strings.Split(forged, ".")[1] + "." + segments[2],
+		"tampered signature": segments[0] + "." + segments[1] + "." + strings.Repeat("A", len(segments[2])),
+		"expired":            expired,
+		"not a JWT":          "opaque",
+	}
+	for name, token := range tests {
+		if body := introspect(t, token); body["active"] != false || len(body) != 1 {
+			t.Errorf("%s: got %v, want only active false", name, body)
+		}
+	}
+}
+
+func TestVerifyHS256(t *testing.T) {
+	claims := map[string]interface{}{"sub": "app"}
+	token, err := signJWT(claims, []byte("other-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := decodeJWT(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verifyHS256(token, decoded, []byte(oauthTestKey)) {
+		t.Error("token signed with another key verified")
+	}
+	if !verifyHS256(token, decoded, []byte("other-key")) {
+		t.Error("token didn't verify with its own key")
+	}
+
+	none := unsignedJWT(t, claims)
+	decoded, err = decodeJWT(none)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verifyHS256(none, decoded, []byte(oauthTestKey)) {
+		t.Error("alg none token verified")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// defaultPatchBase is the document /patch-json patches when no base is given.
+const defaultPatchBase = `{"title":"edgehttpbin","author":{"name":"edgehttpbin","email":"edgehttpbin@example.com"},"tags":["http","edge"]}`
+
+// handlePatchJSON applies the request body as an RFC 7386 JSON Merge Patch
+// to the base64 JSON document in ?base=, or to defaultPatchBase.
+func handlePatchJSON(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	base := []byte(defaultPatchBase)
+	if param := r.URL.Query().Get("base"); param != "" {
+		var err error
+		if base, err = decodeBase64(param); err != nil {
+			fsthttp.Error(w, "Invalid base, must be base64 encoded JSON", fsthttp.StatusBadRequest)
+			return
+		}
+	}
+	var doc interface{}
+	if err := json.Unmarshal(base, &doc); err != nil {
+		fsthttp.Error(w, "Invalid base, must be base64 encoded JSON", fsthttp.StatusBadRequest)
+		return
+	}
+
+	data, err := readBody(r)
+	if err != nil {
+		bodyError(w, err)
+		return
+	}
+	var patch interface{}
+	if err := json.Unmarshal(data, &patch); err != nil {
+		fsthttp.Error(w, "Invalid patch, must be JSON", fsthttp.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, r, fsthttp.StatusOK, mergePatch(doc, patch))
+}
+
+// mergePatch implements the MergePatch function of RFC 7386 section 2. A
+// patch that isn't an object replaces the target, otherwise its members are
+// merged in recursively with null removing a member.
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatch(t[k], v)
+	}
+	return t
+}
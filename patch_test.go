@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPatchJSON(t *testing.T) {
+	base := base64.StdEncoding.EncodeToString([]byte(`{"a":"b","c":{"d":"e","f":"g"}}`))
+	tests := []struct {
+		name, patch, want string
+	}{
+		{"add", `{"h":"i"}`, `{"a":"b","c":{"d":"e","f":"g"},"h":"i"}`},
+		{"overwrite", `{"a":"z"}`, `{"a":"z","c":{"d":"e","f":"g"}}`},
+		{"nested", `{"c":{"f":"z"}}`, `{"a":"b","c":{"d":"e","f":"z"}}`},
+		{"null deletes", `{"a":null,"c":{"f":null}}`, `{"c":{"d":"e"}}`},
+		{"array replaces", `["x"]`, `["x"]`},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "PATCH", "/patch-json?base="+base, strings.NewReader(tt.patch))
+		if rec.status != 200 {
+			t.Errorf("%s: status %d, want 200", tt.name, rec.status)
+			continue
+		}
+		var got, want interface{}
+		json.Unmarshal(rec.body.Bytes(), &got)
+		json.Unmarshal([]byte(tt.want), &want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %s, want %s", tt.name, rec.body.String(), tt.want)
+		}
+	}
+}
+
+func TestPatchJSONInvalid(t *testing.T) {
+	if rec := serve(t, "PATCH", "/patch-json", strings.NewReader("not json")); rec.status != 400 {
+		t.Errorf("invalid patch: status %d, want 400", rec.status)
+	}
+	if rec := serve(t, "PATCH", "/patch-json?base=!!!", strings.NewReader("{}")); rec.status != 400 {
+		t.Errorf("invalid base: status %d, want 400", rec.status)
+	}
+	if rec := serve(t, "PATCH", "/patch-json", strings.NewReader(`{"tags":null}`)); strings.Contains(rec.body.String(), "tags") {
+		t.Errorf("default base: tags were not removed: %s", rec.body.String())
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// proxyChain is the normalized view of a request's forwarding headers. IPs
+// runs from the original client to the proxy closest to the edge.
+type proxyChain struct {
+	Source         string   `json:"source"`
+	IPs            []string `json:"ips"`
+	Scheme         string   `json:"scheme,omitempty"`
+	Host           string   `json:"host,omitempty"`
+	Hops           int      `json:"hops"`
+	Via            []string `json:"via,omitempty"`
+	FastlyClientIP string   `json:"fastly_client_ip,omitempty"`
+}
+
+// handleProxyHeaders summarizes the proxy chain. The RFC 7239 Forwarded
+// header is preferred when present, falling back on the X-Forwarded-*
+// headers otherwise.
+func handleProxyHeaders(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	chain := proxyChain{IPs: []string{}}
+	if forwarded := r.Header.Values("Forwarded"); len(forwarded) > 0 {
+		chain.Source = "forwarded"
+		for _, elem := range splitList(strings.Join(forwarded, ",")) {
+			params := parseForwardedElement(elem)
+			if ip, ok := params["for"]; ok {
+				chain.IPs = append(chain.IPs, forwardedNode(ip))
+			}
+			// The earliest proto and host describe the request as the client
+			// sent it.
+			if chain.Scheme == "" {
+				chain.Scheme = params["proto"]
+			}
+			if chain.Host == "" {
+				chain.Host = params["host"]
+			}
+		}
+	} else {
+		chain.Source = "x-forwarded-for"
+		for _, ip := range splitList(strings.Join(r.Header.Values("X-Forwarded-For"), ",")) {
+			chain.IPs = append(chain.IPs, ip)
+		}
+		chain.Scheme = strings.TrimSpace(strings.Split(r.Header.Get("X-Forwarded-Proto"), ",")[0])
+		chain.Host = strings.TrimSpace(strings.Split(r.Header.Get("X-Forwarded-Host"), ",")[0])
+	}
+	chain.Hops = len(chain.IPs)
+	chain.Via = splitList(strings.Join(r.Header.Values("Via"), ","))
+	chain.FastlyClientIP = strings.TrimSpace(r.Header.Get("Fastly-Client-IP"))
+
+	writeJSON(w, r, fsthttp.StatusOK, chain)
+}
+
+// splitList splits a comma separated header value, dropping empty entries.
+// Commas inside quoted strings don't split.
+func splitList(value string) []string {
+	var items []string
+	var b strings.Builder
+	quoted := false
+	flush := func() {
+		if item := strings.TrimSpace(b.String()); item != "" {
+			items = append(items, item)
+		}
+		b.Reset()
+	}
+	for _, c := range value {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			flush()
+			continue
+		}
+		b.WriteRune(c)
+	}
+	flush()
+	return items
+}
+
+// parseForwardedElement parses the semicolon separated pairs of a single
+// Forwarded element, as in "for=192.0.2.60;proto=http;by=203.0.113.43".
+func parseForwardedElement(elem string) map[string]string {
+	params := map[string]string{}
+	for _, pair := range strings.Split(elem, ";") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		params[strings.ToLower(kv[0])] = strings.Trim(kv[1], `"`)
+	}
+	return params
+}
+
+// forwardedNode reduces a Forwarded node to its address, removing any port
+// and the brackets around IPv6 addresses. Obfuscated identifiers such as
+// "unknown" or "_hidden" are kept as they are.
+func forwardedNode(node string) string {
+	if strings.HasPrefix(node, "[") {
+		if end := strings.IndexByte(node, ']'); end > 0 {
+			return node[1:end]
+		}
+	}
+	if i := strings.LastIndexByte(node, ':'); i > 0 && strings.Count(node, ":") == 1 {
+		return node[:i]
+	}
+	return node
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestProxyHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []string
+		want   proxyChain
+	}{
+		{
+			"x-forwarded-for",
+			[]string{"X-Forwarded-For", "203.0.113.1, 198.51.100.2", "X-Forwarded-For", "192.0.2.3", "X-Forwarded-Proto", "https, http", "X-Forwarded-Host", "example.com"},
+			proxyChain{Source: "x-forwarded-for", IPs: []string{"203.0.113.1", "198.51.100.2", "192.0.2.3"}, Scheme: "https", Host: "example.com", Hops: 3},
+		},
+		{
+			"forwarded",
+			[]string{"Forwarded", `for=192.0.2.60:8080;proto=https;host=example.com, for="[2001:db8::1]:4711"`, "X-Forwarded-For", "203.0.113.9", "Via", "1.1 a, 1.1 b"},
+			proxyChain{Source: "forwarded", IPs: []string{"192.0.2.60", "2001:db8::1"}, Scheme: "https", Host: "example.com", Hops: 2, Via: []string{"1.1 a", "1.1 b"}},
+		},
+		{
+			"obfuscated",
+			[]string{"Forwarded", "for=unknown;by=_hidden", "Fastly-Client-IP", "198.51.100.7"},
+			proxyChain{Source: "forwarded", IPs: []string{"unknown"}, Hops: 1, FastlyClientIP: "198.51.100.7"},
+		},
+		{
+			"none",
+			nil,
+			proxyChain{Source: "x-forwarded-for", IPs: []string{}},
+		},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", "/proxy-headers", nil, tt.header...)
+		var got proxyChain
+		if err := json.Unmarshal(rec.body.Bytes(), &got); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+const (
+	// maxRangeBytes caps the size of the resource served by /range/{n}.
+	maxRangeBytes = 100 * 1024
+
+	// maxRanges caps how many ranges one request may ask for. Overlapping
+	// and adjacent ranges are merged as well, so the parts of a response
+	// never add up to more than the resource itself.
+	maxRanges = 50
+)
+
+type byteRange struct {
+	start, end int // inclusive
+}
+
+func (br byteRange) contentRange(size int) string {
+	return fmt.Sprintf("bytes %d-%d/%d", br.start, br.end, size)
+}
+
+var (
+	errUnsatisfiableRange = errors.New("range not satisfiable")
+	errRangeUnit          = errors.New("invalid range unit")
+	errInvalidRange       = errors.New("invalid range")
+)
+
+// parseRange parses a Range header for a resource of size bytes. Ranges
+// that fall outside the resource are dropped; if none remain the header is
+// unsatisfiable. The rest are sorted and overlapping or adjacent ones merged.
+func parseRange(header string, size int) ([]byteRange, error) {
+	if !strings.HasPrefix(header, "bytes=") {
+		return nil, errRangeUnit
+	}
+
+	specs := strings.Split(strings.TrimPrefix(header, "bytes="), ",")
+	if len(specs) > maxRanges {
+		return nil, errUnsatisfiableRange
+	}
+	var ranges []byteRange
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		dash := strings.IndexByte(spec, '-')
+		if dash < 0 {
+			return nil, errInvalidRange
+		}
+		startStr, endStr := spec[:dash], spec[dash+1:]
+
+		var br byteRange
+		switch {
+		case startStr == "":
+			// A suffix range selects the last n bytes.
+			n, err := strconv.Atoi(endStr)
+			if err != nil || n < 0 {
+				return nil, errInvalidRange
+			}
+			if n == 0 {
+				continue
+			}
+			if n > size {
+				n = size
+			}
+			br = byteRange{size - n, size - 1}
+		default:
+			start, err := strconv.Atoi(startStr)
+			if err != nil || start < 0 {
+				return nil, errInvalidRange
+			}
+			end := size - 1
+			if endStr != "" {
+				end, err = strconv.Atoi(endStr)
+				if err != nil || end < start {
+					return nil, errInvalidRange
+				}
+			}
+			if start >= size {
+				continue
+			}
+			if end >= size {
+				end = size - 1
+			}
+			br = byteRange{start, end}
+		}
+		ranges = append(ranges, br)
+	}
+
+	if len(ranges) == 0 {
+		return nil, errUnsatisfiableRange
+	}
+	return mergeRanges(ranges), nil
+}
+
+func mergeRanges(ranges []byteRange) []byteRange {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+	merged := ranges[:1]
+	for _, br := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if br.start > last.end+1 {
+			merged = append(merged, br)
+			continue
+		}
+		if br.end > last.end {
+			last.end = br.end
+		}
+	}
+	return merged
+}
+
+// rangeData returns n bytes cycling through the lowercase alphabet, so any
+// slice of the resource can be checked by eye.
+func rangeData(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte('a' + i%26)
+	}
+	return data
+}
+
+func handleRange(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) != 3 {
+		fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
+		return
+	}
+
+	size, err := strconv.Atoi(parts[2])
+	if err != nil || size <= 0 || size > maxRangeBytes {
+		fsthttp.Error(w, fmt.Sprintf("Invalid n, must be between 1 and %d", maxRangeBytes), fsthttp.StatusBadRequest)
+		return
+	}
+	data := rangeData(size)
+
+	// The content only depends on n, so the ETag can be too. It lets
+	// clients resume a download with If-Range.
+	etag := fmt.Sprintf(`"range-%d"`, size)
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("ETag", etag)
+
+	// A Range that is malformed or whose unit isn't bytes is ignored, as is
+	// one sent with an If-Range that doesn't match: the client then gets the
+	// whole resource. If-Range must be a strong ETag to match, so dates
+	// never do.
+	header := r.Header.Get("Range")
+	ranges, err := parseRange(header, size)
+	if header == "" || err == errRangeUnit || err == errInvalidRange || (r.Header.Get("If-Range") != "" && r.Header.Get("If-Range") != etag) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(size))
+		w.Write(data)
+		return
+	}
+	if err != nil {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		fsthttp.Error(w, fsthttp.StatusText(416), fsthttp.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	if len(ranges) == 1 {
+		br := ranges[0]
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Range", br.contentRange(size))
+		w.Header().Set("Content-Length", strconv.Itoa(br.end-br.start+1))
+		w.WriteHeader(fsthttp.StatusPartialContent)
+		w.Write(data[br.start : br.end+1])
+		return
+	}
+
+	boundary := newRequestID()
+	var b strings.Builder
+	for _, br := range ranges {
+		fmt.Fprintf(&b, "--%s\r\n", boundary)
+		b.WriteString("Content-Type: application/octet-stream\r\n")
+		fmt.Fprintf(&b, "Content-Range: %s\r\n\r\n", br.contentRange(size))
+		b.Write(data[br.start : br.end+1])
+		b.WriteString("\r\n")
+	}
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+
+	w.Header().Set("Content-Type", "multipart/byteranges; boundary="+boundary)
+	w.Header().Set("Content-Length", strconv.Itoa(b.Len()))
+	w.WriteHeader(fsthttp.StatusPartialContent)
+	w.Write([]byte(b.String()))
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"testing"
+)
+
+func TestRangeSingle(t *testing.T) {
+	tests := []struct {
+		header, contentRange, body string
+	}{
+		{"bytes=0-4", "bytes 0-4/26", "abcde"},
+		{"bytes=-3", "bytes 23-25/26", "xyz"},
+		{"bytes=24-", "bytes 24-25/26", "yz"},
+		{"bytes=20-100", "bytes 20-25/26", "uvwxyz"},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", "/range/26", nil, "Range", tt.header)
+		if rec.status != 206 {
+			t.Errorf("Range %s: status %d, want 206", tt.header, rec.status)
+		}
+		if got := rec.header.Get("Content-Range"); got != tt.contentRange {
+			t.Errorf("Range %s: Content-Range %q, want %q", tt.header, got, tt.contentRange)
+		}
+		if got := rec.body.String(); got != tt.body {
+			t.Errorf("Range %s: body %q, want %q", tt.header, got, tt.body)
+		}
+	}
+}
+
+func TestRangeMultipart(t *testing.T) {
+	rec := serve(t, "GET", "/range/26", nil, "Range", "bytes=0-1, 10-12")
+	if rec.status != 206 {
+		t.Fatalf("status %d, want 206", rec.status)
+	}
+	mediaType, params, err := mime.ParseMediaType(rec.header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/byteranges" {
+		t.Fatalf("Content-Type %q", rec.header.Get("Content-Type"))
+	}
+
+	want := []struct{ contentRange, body string }{
+		{"bytes 0-1/26", "ab"},
+		{"bytes 10-12/26", "klm"},
+	}
+	mr := multipart.NewReader(strings.NewReader(rec.body.String()), params["boundary"])
+	for i := 0; ; i++ {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			if i != len(want) {
+				t.Errorf("got %d parts, want %d", i, len(want))
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i >= len(want) {
+			t.Fatalf("unexpected part %d", i)
+		}
+		body, _ := io.ReadAll(part)
+		if got := part.Header.Get("Content-Range"); got != want[i].contentRange || string(body) != want[i].body {
+			t.Errorf("part %d: Content-Range %q body %q, want %q %q", i, got, body, want[i].contentRange, want[i].body)
+		}
+	}
+}
+
+func TestRangeOverlap(t *testing.T) {
+	// Overlapping and adjacent ranges collapse into one, so repeating a
+	// range can't make the response bigger than the resource.
+	header := "bytes=0-9, 5-14, 15-19, 0-9, 0-9"
+	rec := serve(t, "GET", "/range/26", nil, "Range", header)
+	if rec.status != 206 {
+		t.Fatalf("status %d, want 206", rec.status)
+	}
+	if got := rec.header.Get("Content-Range"); got != "bytes 0-19/26" {
+		t.Errorf("Content-Range %q, want bytes 0-19/26", got)
+	}
+	if got := rec.body.String(); got != "abcdefghijklmnopqrst" {
+		t.Errorf("body %q", got)
+	}
+}
+
+func TestRangeUnsatisfiable(t *testing.T) {
+	for _, header := range []string{"bytes=30-40", "bytes=-0", "bytes=" + strings.Repeat("0-0,", maxRanges) + "0-0"} {
+		rec := serve(t, "GET", "/range/26", nil, "Range", header)
+		if rec.status != 416 {
+			t.Errorf("Range %.20s: status %d, want 416", header, rec.status)
+		}
+		if got := rec.header.Get("Content-Range"); got != "bytes */26" {
+			t.Errorf("Range %.20s: Content-Range %q, want bytes */26", header, got)
+		}
+	}
+}
+
+func TestRangeIgnored(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []string
+	}{
+		{"unknown unit", []string{"Range", "items=0-4"}},
+		{"malformed", []string{"Range", "bytes=abc"}},
+		{"reversed", []string{"Range", "bytes=5-1"}},
+		{"stale If-Range", []string{"Range", "bytes=0-4", "If-Range", `"range-25"`}},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", "/range/26", nil, tt.header...)
+		if rec.status != 200 || rec.body.Len() != 26 {
+			t.Errorf("%s: status %d, %d bytes, want 200 and the whole resource", tt.name, rec.status, rec.body.Len())
+		}
+	}
+
+	rec := serve(t, "GET", "/range/26", nil, "Range", "bytes=0-4", "If-Range", `"range-26"`)
+	if rec.status != 206 || rec.body.String() != "abcde" {
+		t.Errorf("matching If-Range: status %d, body %q", rec.status, rec.body.String())
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+const defaultRateLimitWindow = 60
+
+// rateCounter counts a request against key in the given window and returns
+// the count so far. It's a variable so the counter can be swapped out where
+// the state store isn't wanted.
+var rateCounter = countInWindow
+
+// countInWindow keeps a single "window count" entry per key, restarting the
+// count when the window moves on, so the store holds one entry per client
+// rather than one per client per window. The entry expires after ttl.
+func countInWindow(key string, window int64, ttl time.Duration) (int64, error) {
+	incrementMu.Lock()
+	defer incrementMu.Unlock()
+
+	var stored, n int64
+	if v, ok := loadState(key); ok {
+		if _, err := fmt.Sscanf(v, "%d %d", &stored, &n); err != nil || stored != window {
+			n = 0
+		}
+	}
+	n++
+	return n, storeState(key, fmt.Sprintf("%d %d", window, n), ttl)
+}
+
+// rateLimited limits each client IP to rate_limit requests per
+// rate_limit_window seconds across every route wrapped with it, answering
+// with a 429 past that. Without rate_limit set requests are never limited.
+//
+// The SDK has no edge rate limiter yet, so requests are counted in fixed
+// windows in the state store. The store has no atomic increment, so counting
+// is a read followed by a write: concurrent requests at different instances
+// can read the same count, undercounting and letting a burst slightly
+// overshoot the limit.
+func rateLimited(h fsthttp.HandlerFunc) fsthttp.HandlerFunc {
+	return func(ctx context.Context, w fsthttp.ResponseWriter, r *fsthttp.Request) {
+		limit := configInt("rate_limit", 0)
+		if limit == 0 {
+			h(ctx, w, r)
+			return
+		}
+		window := int64(configInt("rate_limit_window", defaultRateLimitWindow))
+
+		now := time.Now().Unix()
+		bucket := now / window
+		count, err := rateCounter("ratelimit/"+clientIP(r), bucket, time.Duration(window)*time.Second)
+		if err != nil {
+			// Fail open, the limit is a guard rather than a feature.
+			h(ctx, w, r)
+			return
+		}
+		if count > int64(limit) {
+			w.Header().Set("Retry-After", strconv.FormatInt((bucket+1)*window-now, 10))
+			fsthttp.Error(w, fsthttp.StatusText(fsthttp.StatusTooManyRequests), fsthttp.StatusTooManyRequests)
+			return
+		}
+		h(ctx, w, r)
+	}
+}
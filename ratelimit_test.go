@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fakeRateCounter replaces the rate counter for the rest of the test.
+func fakeRateCounter(t *testing.T, counter func(key string, window int64, ttl time.Duration) (int64, error)) {
+	saved := rateCounter
+	t.Cleanup(func() { rateCounter = saved })
+	rateCounter = counter
+}
+
+func TestRateLimit(t *testing.T) {
+	fakeConfig(t, map[string]string{"rate_limit": "2", "rate_limit_window": "60"})
+	counts := map[string]int64{}
+	fakeRateCounter(t, func(key string, window int64, ttl time.Duration) (int64, error) {
+		if ttl != time.Minute {
+			t.Errorf("ttl %v, want the 60s window", ttl)
+		}
+		counts[key]++
+		return counts[key], nil
+	})
+
+	for i := 1; i <= 2; i++ {
+		if rec := serve(t, "GET", "/bytes/16", nil); rec.status != 200 {
+			t.Errorf("request %d: status %d, want 200", i, rec.status)
+		}
+	}
+	rec := serve(t, "GET", "/bytes/16", nil)
+	if rec.status != 429 {
+		t.Errorf("request 3: status %d, want 429", rec.status)
+	}
+	if retry, err := strconv.Atoi(rec.header.Get("Retry-After")); err != nil || retry < 1 || retry > 60 {
+		t.Errorf("Retry-After %q, want 1 to 60 seconds", rec.header.Get("Retry-After"))
+	}
+	if _, ok := counts["ratelimit/192.0.2.1"]; !ok || len(counts) != 1 {
+		t.Errorf("counted under %v, want one key for the client", counts)
+	}
+
+	// Routes that aren't wrapped are never counted.
+	if rec := serve(t, "GET", "/uuid", nil); rec.status != 200 {
+		t.Errorf("/uuid: status %d, want 200", rec.status)
+	}
+}
+
+func TestRateLimitFailOpen(t *testing.T) {
+	fakeConfig(t, map[string]string{"rate_limit": "1"})
+	fakeRateCounter(t, func(string, int64, time.Duration) (int64, error) {
+		return 0, errors.New("store unavailable")
+	})
+	for i := 0; i < 3; i++ {
+		if rec := serve(t, "GET", "/bytes/16", nil); rec.status != 200 {
+			t.Errorf("request %d: status %d, want 200", i, rec.status)
+		}
+	}
+}
+
+func TestRateLimitUnset(t *testing.T) {
+	fakeConfig(t, nil)
+	fakeRateCounter(t, func(string, int64, time.Duration) (int64, error) {
+		t.Error("counted a request without rate_limit set")
+		return 0, nil
+	})
+	serve(t, "GET", "/bytes/16", nil)
+}
+
+func TestCountInWindow(t *testing.T) {
+	const key = "ratelimit/test-count-in-window"
+	for want := int64(1); want <= 3; want++ {
+		if n, err := countInWindow(key, 100, time.Minute); err != nil || n != want {
+			t.Fatalf("window 100: count %d, %v, want %d", n, err, want)
+		}
+	}
+	if n, _ := countInWindow(key, 101, time.Minute); n != 1 {
+		t.Errorf("next window: count %d, want 1", n)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+const defaultMaxRedirects = 20
+
+var (
+	maxRedirectsOnce  sync.Once
+	maxRedirectsValue int
+)
+
+// maxRedirects returns the longest redirect chain, from the redirect_max
+// config key.
+func maxRedirects() int {
+	maxRedirectsOnce.Do(func() {
+		maxRedirectsValue = configInt("redirect_max", defaultMaxRedirects)
+	})
+	return maxRedirectsValue
+}
+
+// handleRedirectChain serves /redirect/{n}, which redirects relatively
+// unless ?absolute=true asks for the /absolute-redirect chain instead.
+func handleRedirectChain(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	if absolute, _ := strconv.ParseBool(r.URL.Query().Get("absolute")); absolute {
+		handleRedirect(w, r, absoluteRedirect(r))
+		return
+	}
+	handleRedirect(w, r, relativeRedirect("/redirect"))
+}
+
+func handleRelativeRedirect(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	handleRedirect(w, r, relativeRedirect("/relative-redirect"))
+}
+
+func handleAbsoluteRedirect(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	handleRedirect(w, r, absoluteRedirect(r))
+}
+
+// handleRedirect serves one hop of a /<kind>/{n} redirect chain, using
+// location to build the Location of the next hop.
+func handleRedirect(w fsthttp.ResponseWriter, r *fsthttp.Request, location func(n int) string) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) != 3 {
+		fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
+		return
+	}
+
+	redirects, err := strconv.Atoi(parts[2])
+	if err != nil || redirects < 0 {
+		fsthttp.Error(w, "Invalid redirects", fsthttp.StatusBadRequest)
+		return
+	}
+	if redirects == 0 {
+		// The end of the chain describes the request as /anything does, so
+		// clients can check which method and body a 303, 307 or 308 left
+		// them with.
+		handleAnything(w, r)
+		return
+	}
+	if max := maxRedirects(); redirects > max {
+		fsthttp.Error(w, fmt.Sprintf("maximum of %d redirects allowed", max), fsthttp.StatusBadRequest)
+		return
+	}
+
+	code := 302
+	if param := r.URL.Query().Get("status_code"); param != "" {
+		code, err = strconv.Atoi(param)
+		if err != nil || !redirectCodes[code] {
+			fsthttp.Error(w, "Invalid status_code, must be one of 301, 302, 303, 307 or 308", fsthttp.StatusBadRequest)
+			return
+		}
+	}
+
+	// Carry the query along so every hop uses the same status code.
+	next := location(redirects - 1)
+	if r.URL.RawQuery != "" {
+		next += "?" + r.URL.RawQuery
+	}
+	w.Header().Set("Location", next)
+	w.WriteHeader(code)
+}
+
+// handleRedirectTo redirects to ?url=, with an optional ?status_code=
+// between 300 and 399 that defaults to 302. The target may be absolute or
+// relative, and is re-encoded so the Location header is always valid.
+func handleRedirectTo(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	query := r.URL.Query()
+	target, err := url.Parse(query.Get("url"))
+	if err != nil || query.Get("url") == "" {
+		fsthttp.Error(w, "Invalid url", fsthttp.StatusBadRequest)
+		return
+	}
+
+	code := fsthttp.StatusFound
+	if param := query.Get("status_code"); param != "" {
+		code, err = strconv.Atoi(param)
+		if err != nil || code < 300 || code > 399 {
+			fsthttp.Error(w, "Invalid status_code, must be between 300 and 399", fsthttp.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Location", escapeLocation(target.String()))
+	w.WriteHeader(code)
+}
+
+// escapeLocation percent-encodes the bytes url.URL.String leaves alone in
+// the query and fragment but that aren't allowed in a Location header:
+// spaces, control characters and anything outside ASCII.
+func escapeLocation(location string) string {
+	var b strings.Builder
+	for i := 0; i < len(location); i++ {
+		if c := location[i]; c <= ' ' || c >= 0x7f {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+var redirectCodes = map[int]bool{
+	fsthttp.StatusMovedPermanently:  true,
+	fsthttp.StatusFound:             true,
+	fsthttp.StatusSeeOther:          true,
+	fsthttp.StatusTemporaryRedirect: true,
+	fsthttp.StatusPermanentRedirect: true,
+}
+
+func relativeRedirect(prefix string) func(n int) string {
+	return func(n int) string {
+		return fmt.Sprintf("%s/%d", prefix, n)
+	}
+}
+
+// absoluteRedirect builds Locations from the scheme and host of the request,
+// so the chain stays on whichever hostname the client used.
+func absoluteRedirect(r *fsthttp.Request) func(n int) string {
+	return func(n int) string {
+		return fmt.Sprintf("%s/absolute-redirect/%d", requestOrigin(r), n)
+	}
+}
+
+// requestOrigin reconstructs the scheme and host the client used to reach
+// us.
+func requestOrigin(r *fsthttp.Request) string {
+	scheme := r.URL.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+	return scheme + "://" + host
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRedirectLocation(t *testing.T) {
+	tests := []struct {
+		target, want string
+	}{
+		{"https://edgehttpbin.test/redirect/3", "/redirect/2"},
+		{"https://edgehttpbin.test/relative-redirect/3", "/relative-redirect/2"},
+		{"https://edgehttpbin.test/absolute-redirect/3", "https://edgehttpbin.test/absolute-redirect/2"},
+		{"https://edgehttpbin.test/redirect/3?absolute=true", "https://edgehttpbin.test/absolute-redirect/2?absolute=true"},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", tt.target, nil)
+		if rec.status != 302 {
+			t.Errorf("GET %s: status %d, want 302", tt.target, rec.status)
+		}
+		if got := rec.header.Get("Location"); got != tt.want {
+			t.Errorf("GET %s: Location %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestRedirectEnd(t *testing.T) {
+	for _, target := range []string{"/redirect/0", "/relative-redirect/0", "/absolute-redirect/0"} {
+		rec := serve(t, "GET", target, nil)
+		if rec.status != 200 || rec.header.Get("Location") != "" {
+			t.Errorf("GET %s: status %d, Location %q, want a 200", target, rec.status, rec.header.Get("Location"))
+		}
+	}
+}
+
+func TestRedirectInvalidCount(t *testing.T) {
+	for _, n := range []string{"-1", "x", "21"} {
+		for _, prefix := range []string{"/redirect/", "/relative-redirect/", "/absolute-redirect/"} {
+			rec := serve(t, "GET", prefix+n, nil)
+			if rec.status != 400 {
+				t.Errorf("GET %s%s: status %d, want 400", prefix, n, rec.status)
+			}
+			if loc := rec.header.Get("Location"); strings.Contains(loc, "redirect") {
+				t.Errorf("GET %s%s: redirected to %s", prefix, n, loc)
+			}
+		}
+	}
+}
+
+func TestRedirectStatusCode(t *testing.T) {
+	target := "/redirect/2?status_code=308"
+	for hops := 0; hops < 2; hops++ {
+		rec := serve(t, "GET", target, nil)
+		if rec.status != 308 {
+			t.Fatalf("GET %s: status %d, want 308", target, rec.status)
+		}
+		target = rec.header.Get("Location")
+	}
+	if target != "/redirect/0?status_code=308" {
+		t.Fatalf("chain ends at %s", target)
+	}
+	if rec := serve(t, "GET", target, nil); rec.status != 200 {
+		t.Errorf("GET %s: status %d, want 200", target, rec.status)
+	}
+
+	if rec := serve(t, "GET", "/redirect/2?status_code=200", nil); rec.status != 400 {
+		t.Errorf("status_code=200: status %d, want 400", rec.status)
+	}
+}
+
+func TestRedirectTo(t *testing.T) {
+	tests := []struct {
+		query  string
+		status int
+	}{
+		{"", 400},
+		{"url=", 400},
+		{"url=https%3A%2F%2Fexample.com%2F", 302},
+		{"url=https%3A%2F%2Fexample.com%2F&status_code=307", 307},
+		{"url=https%3A%2F%2Fexample.com%2F&status_code=308", 308},
+		{"url=https%3A%2F%2Fexample.com%2F&status_code=200", 400},
+		{"url=https%3A%2F%2Fexample.com%2F&status_code=400", 400},
+		{"url=https%3A%2F%2Fexample.com%2F&status_code=3xx", 400},
+		{"url=https%3A%2F%2Fexample.com%2F100%2525", 302},
+		{"url=https%3A%2F%2Fexample.com%2F100%25", 400},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", "/redirect-to?"+tt.query, nil)
+		if rec.status != tt.status {
+			t.Errorf("GET /redirect-to?%s: status %d, want %d", tt.query, rec.status, tt.status)
+		}
+		if tt.status == 400 && rec.header.Get("Location") != "" {
+			t.Errorf("GET /redirect-to?%s: Location %q on an error", tt.query, rec.header.Get("Location"))
+		}
+	}
+}
+
+func TestRedirectToEscaping(t *testing.T) {
+	tests := []struct {
+		target, want string
+	}{
+		{"https://example.com/a b?q=x y#f g", "https://example.com/a%20b?q=x%20y#f%20g"},
+		{"https://example.com/café?name=zoë", "https://example.com/caf%C3%A9?name=zo%C3%AB"},
+		{"https://example.com/100%25?p=50%&q=%zz", "https://example.com/100%25?p=50%&q=%zz"},
+		{"https://example.com/?next=/a%2Fb", "https://example.com/?next=/a%2Fb"},
+		{"/relative path", "/relative%20path"},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", "/redirect-to?url="+url.QueryEscape(tt.target), nil)
+		if got := rec.header.Get("Location"); got != tt.want {
+			t.Errorf("redirect to %q: Location %q, want %q", tt.target, got, tt.want)
+		}
+	}
+	if got := escapeLocation("/a\tb\x7f\xff"); got != "/a%09b%7F%FF" {
+		t.Errorf("escapeLocation: got %q, want /a%%09b%%7F%%FF", got)
+	}
+}
+
+func TestRedirectMax(t *testing.T) {
+	fakeConfig(t, map[string]string{"redirect_max": "3"})
+	for _, prefix := range []string{"/redirect/", "/relative-redirect/", "/absolute-redirect/"} {
+		if rec := serve(t, "GET", prefix+"4", nil); rec.status != 400 || rec.header.Get("Location") != "" {
+			t.Errorf("GET %s4: status %d, Location %q, want a 400", prefix, rec.status, rec.header.Get("Location"))
+		}
+		if rec := serve(t, "GET", prefix+"3", nil); rec.status != 302 {
+			t.Errorf("GET %s3: status %d, want 302", prefix, rec.status)
+		}
+	}
+}
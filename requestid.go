@@ -0,0 +1,39 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// requestIDs holds the ID assigned to each in-flight request, so handlers
+// can report it without threading it through every signature.
+var requestIDs sync.Map
+
+// assignRequestID reuses the client's X-Request-Id or generates a new one,
+// and returns a function releasing it once the request is done.
+func assignRequestID(w fsthttp.ResponseWriter, r *fsthttp.Request) func() {
+	id := r.Header.Get("X-Request-Id")
+	if id == "" {
+		id = newRequestID()
+	}
+	requestIDs.Store(r, id)
+	w.Header().Set("X-Request-Id", id)
+	return func() { requestIDs.Delete(r) }
+}
+
+// requestID returns the ID assigned to r by the dispatcher.
+func requestID(r *fsthttp.Request) string {
+	if id, ok := requestIDs.Load(r); ok {
+		return id.(string)
+	}
+	return ""
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return fmt.Sprintf("%x", b)
+}
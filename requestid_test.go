@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	rec := serve(t, "GET", "/get", nil)
+	id := rec.header.Get("X-Request-Id")
+	if !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(id) {
+		t.Errorf("generated X-Request-Id %q", id)
+	}
+	var body requestInfo
+	if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.RequestID != id {
+		t.Errorf("request_id %q, want %q", body.RequestID, id)
+	}
+
+	rec = serve(t, "GET", "/anything", nil, "X-Request-Id", "client-id-1")
+	if got := rec.header.Get("X-Request-Id"); got != "client-id-1" {
+		t.Errorf("X-Request-Id %q, want the client's", got)
+	}
+	if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.RequestID != "client-id-1" {
+		t.Errorf("request_id %q, want the client's", body.RequestID)
+	}
+}
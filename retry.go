@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+const (
+	defaultRetryFailures = 3
+	maxRetryFailures     = 10
+
+	// retryTTL is how long attempts are remembered after the last one, so a
+	// key can be reused once a test run is over.
+	retryTTL = 5 * time.Minute
+)
+
+// handleRetry fails the first ?failures= attempts for a ?key= with a 503,
+// then succeeds, for exercising client retry and backoff logic. The failure
+// count can also be given in the path, as /retry/{n}. Retry-After doubles
+// with every failed attempt, and is sent as an HTTP-date with ?date=true.
+// ?status=429 fails with a 429 instead.
+func handleRetry(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	w.Header().Set("Cache-Control", "no-store, max-age=0")
+
+	query := r.URL.Query()
+	key := query.Get("key")
+	if key == "" {
+		fsthttp.Error(w, "Missing key", fsthttp.StatusBadRequest)
+		return
+	}
+	failures := configInt("retry_failures", defaultRetryFailures)
+	param := query.Get("failures")
+	if parts := strings.Split(r.URL.Path, "/"); len(parts) == 3 {
+		param = parts[2]
+	}
+	if param != "" {
+		n, err := strconv.Atoi(param)
+		if err != nil || n < 0 || n > maxRetryFailures {
+			fsthttp.Error(w, fmt.Sprintf("Invalid failures, must be between 0 and %d", maxRetryFailures), fsthttp.StatusBadRequest)
+			return
+		}
+		failures = n
+	}
+	status := fsthttp.StatusServiceUnavailable
+	if param := query.Get("status"); param != "" {
+		status, _ = strconv.Atoi(param)
+		if status != fsthttp.StatusTooManyRequests && status != fsthttp.StatusServiceUnavailable {
+			fsthttp.Error(w, "Invalid status, must be 429 or 503", fsthttp.StatusBadRequest)
+			return
+		}
+	}
+	date, _ := strconv.ParseBool(query.Get("date"))
+
+	attempt, err := retryAttempt(key)
+	if err != nil {
+		fsthttp.Error(w, err.Error(), fsthttp.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("X-Retry-Attempt", strconv.Itoa(attempt))
+	if attempt <= failures {
+		wait := 1 << (attempt - 1)
+		if date {
+			w.Header().Set("Retry-After", time.Now().Add(time.Duration(wait)*time.Second).UTC().Format(http.TimeFormat))
+		} else {
+			w.Header().Set("Retry-After", strconv.Itoa(wait))
+		}
+		writeJSON(w, r, status, map[string]interface{}{
+			"key": key, "attempt": attempt, "failures": failures, "ok": false,
+		})
+		return
+	}
+	writeJSON(w, r, fsthttp.StatusOK, map[string]interface{}{
+		"key": key, "attempt": attempt, "failures": failures, "ok": true,
+	})
+}
+
+// retryAttempt records an attempt for key and returns its number. The
+// count starts over once retryTTL passes without an attempt.
+func retryAttempt(key string) (int, error) {
+	n, err := incrementCounter("retry/"+key, retryTTL)
+	return int(n), err
+}
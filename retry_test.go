@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		target string
+		fail   int
+		date   bool
+	}{
+		{"/retry/2?key=test-retry", 503, false},
+		{"/retry/2?key=test-retry-429&status=429", 429, false},
+		{"/retry/2?key=test-retry-date&date=true", 503, true},
+	}
+	for _, tt := range tests {
+		for attempt, want := range []int{tt.fail, tt.fail, 200, 200} {
+			rec := serve(t, "GET", tt.target, nil)
+			if rec.status != want {
+				t.Errorf("GET %s attempt %d: status %d, want %d", tt.target, attempt+1, rec.status, want)
+			}
+			if got := rec.header.Get("X-Retry-Attempt"); got != strconv.Itoa(attempt+1) {
+				t.Errorf("GET %s attempt %d: X-Retry-Attempt %q", tt.target, attempt+1, got)
+			}
+
+			retryAfter := rec.header.Get("Retry-After")
+			if want == 200 {
+				if retryAfter != "" {
+					t.Errorf("GET %s attempt %d: Retry-After %q on a 200", tt.target, attempt+1, retryAfter)
+				}
+				continue
+			}
+			wait := time.Duration(1<<attempt) * time.Second
+			if !tt.date {
+				if retryAfter != strconv.Itoa(int(wait/time.Second)) {
+					t.Errorf("GET %s attempt %d: Retry-After %q, want %d", tt.target, attempt+1, retryAfter, wait/time.Second)
+				}
+				continue
+			}
+			at, err := http.ParseTime(retryAfter)
+			if err != nil {
+				t.Errorf("GET %s attempt %d: Retry-After %q isn't an HTTP-date: %v", tt.target, attempt+1, retryAfter, err)
+				continue
+			}
+			// HTTP-dates are truncated to the second.
+			if until := time.Until(at); until <= wait-2*time.Second || until > wait {
+				t.Errorf("GET %s attempt %d: Retry-After %s is %s away, want about %s", tt.target, attempt+1, retryAfter, until, wait)
+			}
+		}
+	}
+	if rec := serve(t, "GET", "/retry/2?key=test-retry-other", nil); rec.status != 503 {
+		t.Errorf("new key: status %d, want 503", rec.status)
+	}
+	if rec := serve(t, "GET", "/retry/2?key=test-retry-500&status=500", nil); rec.status != 400 {
+		t.Errorf("status=500: status %d, want 400", rec.status)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// robotsDisallow lists the paths /robots.txt asks crawlers to keep out of.
+// Responses under them also carry X-Robots-Tag, so the two always agree.
+var robotsDisallow = []string{"/deny"}
+
+func handleRobotsTxt(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	for _, p := range robotsDisallow {
+		fmt.Fprintf(&b, "Disallow: %s\n", p)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
+
+// robotsDisallowed reports whether robots.txt disallows path. As in
+// robots.txt, each entry matches every path it's a prefix of.
+func robotsDisallowed(path string) bool {
+	for _, p := range robotsDisallow {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
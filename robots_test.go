@@ -0,0 +1,27 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRobots(t *testing.T) {
+	rec := serve(t, "GET", "/robots.txt", nil)
+	for _, p := range robotsDisallow {
+		if !strings.Contains(rec.body.String(), "Disallow: "+p+"\n") {
+			t.Errorf("robots.txt does not disallow %s:\n%s", p, rec.body.String())
+		}
+	}
+
+	tests := []struct {
+		target, tag string
+	}{
+		{"/deny", "none"},
+		{"/ip", ""},
+	}
+	for _, tt := range tests {
+		if got := serve(t, "GET", tt.target, nil).header.Get("X-Robots-Tag"); got != tt.tag {
+			t.Errorf("GET %s: X-Robots-Tag %q, want %q", tt.target, got, tt.tag)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+var (
+	anyMethod = []string(nil)
+	getOnly   = []string{"GET", "HEAD"}
+)
+
+// route maps a path to its handler. Paths ending in a slash with prefix set
+// match everything below them, all others must match exactly. A nil methods
+// list allows every method. The description and example are listed by
+// /routes, the example being a path to try for routes that take parameters.
+type route struct {
+	path        string
+	prefix      bool
+	methods     []string
+	handler     fsthttp.HandlerFunc
+	description string
+	example     string
+}
+
+// requestURIs holds the request URI of each in-flight request as the client
+// sent it, before a trailing slash was trimmed for routing.
+var requestURIs sync.Map
+
+// clientRequestURI returns the request URI of r as the client sent it. It's
+// what the client signs in schemes such as Digest auth.
+func clientRequestURI(r *fsthttp.Request) string {
+	if uri, ok := requestURIs.Load(r); ok {
+		return uri.(string)
+	}
+	return r.URL.RequestURI()
+}
+
+// trimTrailingSlash reports whether p should lose its trailing slash: any
+// path but the root, except the bare prefix of a prefix route such as
+// /cache/purgeable/, which names that route rather than its parent.
+func trimTrailingSlash(p string) bool {
+	if len(p) <= 1 || !strings.HasSuffix(p, "/") {
+		return false
+	}
+	for _, rt := range routes {
+		if rt.prefix && rt.path == p {
+			return false
+		}
+	}
+	return true
+}
+
+// routes is searched in order, so more specific paths must come before any
+// prefix that would also match them. It's filled in by init because
+// /self-test dispatches back through handler.
+var routes []route
+
+func init() {
+	routes = []route{
+		{path: "/", methods: getOnly, handler: plain(handleIndex), description: "This page"},
+		{path: "/absolute-redirect/", prefix: true, methods: anyMethod, handler: plain(handleAbsoluteRedirect), description: "302 absolute redirects n times", example: "/absolute-redirect/3"},
+		{path: "/anything", methods: anyMethod, handler: plain(handleAnything), description: "Returns anything that is passed to the request"},
+		{path: "/anything/", prefix: true, methods: anyMethod, handler: plain(handleAnything), description: "Returns anything that is passed to the request", example: "/anything/foo"},
+		{path: "/base32/", prefix: true, methods: getOnly, handler: plain(handleBase32), description: "Decodes or encodes Base32", example: "/base32/NBSWY3DP"},
+		{path: "/base64/", prefix: true, methods: getOnly, handler: plain(handleBase64), description: "Decodes or encodes Base64", example: "/base64/aGVsbG8="},
+		{path: "/basic-auth", methods: getOnly, handler: plain(handleBasicAuth), description: "Challenges HTTP Basic Auth for the users in the secret store"},
+		{path: "/basic-auth/", prefix: true, methods: getOnly, handler: plain(handleBasicAuth), description: "Challenges HTTP Basic Auth", example: "/basic-auth/user/passwd"},
+		{path: "/bearer", methods: getOnly, handler: plain(handleBearer), description: "Checks for a Bearer token"},
+		{path: "/bearer/jwt", methods: getOnly, handler: plain(handleBearerJWT), description: "Checks the Bearer token is a valid JWT and returns its claims"},
+		{path: "/brotli", methods: getOnly, handler: plain(handleBrotli), description: "Returns brotli-encoded data", example: "/brotli?quality=5"},
+		{path: "/bytes/", prefix: true, methods: getOnly, handler: rateLimited(plain(handleBytes)), description: "Generates n random bytes", example: "/bytes/1024"},
+		{path: "/cache", methods: getOnly, handler: plain(handleCache), description: "Returns 304 for conditional requests"},
+		{path: "/cache/purgeable/", prefix: true, methods: getOnly, handler: plain(handleCachePurgeable), description: "Returns a response cached under a Surrogate-Key", example: "/cache/purgeable/example"},
+		{path: "/cache/vary", methods: getOnly, handler: plain(handleCacheVary), description: "Returns a greeting that varies on Accept-Language"},
+		{path: "/cache/", prefix: true, methods: getOnly, handler: plain(handleCacheFor), description: "Sets Cache-Control for n seconds", example: "/cache/60"},
+		{path: "/cookies", methods: getOnly, handler: plain(handleCookies), description: "Returns cookie data"},
+		{path: "/cookies/delete", methods: getOnly, handler: plain(handleDeleteCookies), description: "Deletes cookies", example: "/cookies/delete?name"},
+		{path: "/cookies/set", methods: getOnly, handler: plain(handleSetCookies), description: "Sets cookies from the query", example: "/cookies/set?name=value"},
+		{path: "/cookies/set/", prefix: true, methods: getOnly, handler: plain(handleSetCookies), description: "Sets a single cookie", example: "/cookies/set/name/value"},
+		{path: "/cors", methods: getOnly, handler: plain(handleCORS), description: "Reports whether the Origin is allowed by CORS"},
+		{path: "/debug/edge", methods: getOnly, handler: plain(handleDebugEdge), description: "Returns the serving POP, protocol and TLS details"},
+		{path: "/delay/", prefix: true, methods: anyMethod, handler: handleDelay, description: "Delays responding for n seconds", example: "/delay/2"},
+		{path: "/delete", methods: []string{"DELETE"}, handler: plain(handleEcho), description: "Returns the DELETE request data"},
+		{path: "/deny", methods: getOnly, handler: plain(handleDeny), description: "Denied by robots.txt"},
+		{path: "/digest-auth/", prefix: true, methods: anyMethod, handler: plain(handleDigestAuth), description: "Challenges HTTP Digest Auth", example: "/digest-auth/auth/user/passwd/SHA-256"},
+		{path: "/drip", methods: getOnly, handler: handleDrip, description: "Drips bytes over a duration after a delay", example: "/drip?numbytes=5&duration=5&delay=1&code=200"},
+		{path: "/dump", methods: anyMethod, handler: plain(handleDump), description: "Returns the raw request as text"},
+		{path: "/dump/request", methods: anyMethod, handler: plain(handleDump), description: "Returns the request in HTTP/1.x wire format"},
+		{path: "/encoding/utf8", methods: getOnly, handler: plain(handleEncodingUTF8), description: "Returns a page of UTF-8 data"},
+		{path: "/etag/", prefix: true, methods: getOnly, handler: plain(handleETag), description: "Responds to If-None-Match and If-Match for the given ETag", example: "/etag/example"},
+		{path: "/forms/post", methods: getOnly, handler: plain(handleFormsPost), description: "HTML form that submits to /post"},
+		{path: "/geo", methods: getOnly, handler: plain(handleGeo), description: "Returns the geolocation of the client IP"},
+		{path: "/get", methods: getOnly, handler: plain(handleGet), description: "Returns the query arguments, headers, origin and URL"},
+		{path: "/gzip-bomb-safe", methods: []string{"POST"}, handler: plain(handleGzipBombSafe), description: "Reports the decompressed size of a gzip body"},
+		{path: "/hash/", prefix: true, methods: getOnly, handler: plain(handleHash), description: "Returns a digest of the value", example: "/hash/sha256/hello"},
+		{path: "/headers", methods: getOnly, handler: plain(handleHeaders), description: "Returns the request headers"},
+		{path: "/hidden-basic-auth", methods: getOnly, handler: plain(handleHiddenBasicAuth), description: "HTTP Basic Auth for the users in the secret store, returning 404 on failure"},
+		{path: "/hidden-basic-auth/", prefix: true, methods: getOnly, handler: plain(handleHiddenBasicAuth), description: "HTTP Basic Auth returning 404 on failure", example: "/hidden-basic-auth/user/passwd"},
+		{path: "/hmac/", prefix: true, methods: anyMethod, handler: plain(handleHMAC), description: "Checks the X-Signature HMAC of the request", example: "/hmac/sha256"},
+		{path: "/html", methods: getOnly, handler: plain(handleHTML), description: "Renders an HTML page"},
+		{path: "/image", methods: getOnly, handler: plain(handleImage), description: "Returns an image based on the Accept header"},
+		{path: "/image/", prefix: true, methods: getOnly, handler: plain(handleImage), description: "Returns an image of the given type", example: "/image/png"},
+		{path: "/ip", methods: getOnly, handler: plain(handleIP), description: "Returns the origin IP"},
+		{path: "/json", methods: getOnly, handler: plain(handleJSON), description: "Returns JSON"},
+		{path: "/jwt/verify", methods: getOnly, handler: handleJWTVerify, description: "Validates the Bearer JWT against the configured key set"},
+		{path: "/links/", prefix: true, methods: getOnly, handler: plain(handleLinks), description: "Returns a page of n links", example: "/links/10/0"},
+		{path: "/multipart", methods: []string{"POST"}, handler: plain(handleMultipart), description: "Describes the parts of a multipart/form-data body"},
+		{path: "/oauth/introspect", methods: []string{"POST"}, handler: plain(handleOAuthIntrospect), description: "Reports whether an /oauth/token token is active"},
+		{path: "/oauth/token", methods: []string{"POST"}, handler: plain(handleOAuthToken), description: "Issues short lived test tokens for the client_credentials and password grants"},
+		{path: "/patch", methods: []string{"PATCH"}, handler: plain(handleEcho), description: "Returns the PATCH request data"},
+		{path: "/patch-json", methods: []string{"PATCH"}, handler: plain(handlePatchJSON), description: "Applies a JSON Merge Patch to a document"},
+		{path: "/post", methods: []string{"POST"}, handler: plain(handleEcho), description: "Returns the POST request data"},
+		{path: "/proxy", methods: anyMethod, handler: handleProxy, description: "Forwards the request to an allowed host", example: "/proxy?url=https%3A%2F%2Fexample.com%2F"},
+		{path: "/proxy-headers", methods: getOnly, handler: plain(handleProxyHeaders), description: "Summarizes the proxy chain"},
+		{path: "/put", methods: []string{"PUT"}, handler: plain(handleEcho), description: "Returns the PUT request data"},
+		{path: "/range/", prefix: true, methods: getOnly, handler: plain(handleRange), description: "Returns n bytes, honouring Range headers", example: "/range/1024"},
+		{path: "/redirect-to", methods: anyMethod, handler: plain(handleRedirectTo), description: "Redirects to the given URL", example: "/redirect-to?url=http%3A%2F%2Fexample.com%2F&status_code=307"},
+		{path: "/redirect/", prefix: true, methods: anyMethod, handler: plain(handleRedirectChain), description: "302 redirects n times", example: "/redirect/3"},
+		{path: "/relative-redirect/", prefix: true, methods: anyMethod, handler: plain(handleRelativeRedirect), description: "302 relative redirects n times", example: "/relative-redirect/3"},
+		{path: "/release/", prefix: true, methods: anyMethod, handler: plain(handleRelease), description: "Releases a request waiting on /wait/:id", example: "/release/example"},
+		{path: "/response-headers", methods: []string{"GET", "HEAD", "POST"}, handler: plain(handleResponseHeaders), description: "Returns the given response headers", example: "/response-headers?Server=httpbin"},
+		{path: "/retry", methods: getOnly, handler: plain(handleRetry), description: "Fails the first n attempts for a key with a 503", example: "/retry?key=example&failures=3"},
+		{path: "/retry/", prefix: true, methods: getOnly, handler: plain(handleRetry), description: "Fails the first n attempts for a key", example: "/retry/3?key=example"},
+		{path: "/robots.txt", methods: getOnly, handler: plain(handleRobotsTxt), description: "Returns some robots.txt rules"},
+		{path: "/routes", methods: getOnly, handler: plain(handleRoutes), description: "Lists the endpoints, their methods and an example path"},
+		{path: "/self-test", methods: getOnly, handler: handleSelfTest, description: "Runs a sample of endpoints and reports which are healthy"},
+		{path: "/sse/", prefix: true, methods: getOnly, handler: handleSSE, description: "Streams n Server-Sent Events", example: "/sse/5"},
+		{path: "/status/", prefix: true, methods: getOnly, handler: plain(handleStatus), description: "Returns the given status code", example: "/status/418"},
+		{path: "/stream/", prefix: true, methods: getOnly, handler: handleStream, description: "Streams n lines of JSON", example: "/stream/10"},
+		{path: "/time", methods: getOnly, handler: plain(handleTime), description: "Returns the current edge time", example: "/time?tz=Europe/London"},
+		{path: "/unstable", methods: getOnly, handler: plain(handleUnstable), description: "Fails half the time"},
+		{path: "/user-agent", methods: getOnly, handler: plain(handleUserAgent), description: "Returns the user-agent"},
+		{path: "/uuid", methods: getOnly, handler: plain(handleUUID), description: "Returns a v4 UUID, or v7 with ?version=7"},
+		{path: "/version", methods: getOnly, handler: plain(handleVersion), description: "Returns the deployed build version"},
+		{path: "/wait/", prefix: true, methods: getOnly, handler: handleWait, description: "Blocks until /release/:id is called", example: "/wait/example"},
+		{path: "/ws", methods: getOnly, handler: plain(handleWebSocket), description: "Reserved for a WebSocket echo"},
+		{path: "/xml", methods: getOnly, handler: plain(handleXML), description: "Returns some XML"},
+	}
+}
+
+// plain adapts handlers that have no use for the request context.
+func plain(h func(fsthttp.ResponseWriter, *fsthttp.Request)) fsthttp.HandlerFunc {
+	return func(ctx context.Context, w fsthttp.ResponseWriter, r *fsthttp.Request) {
+		h(w, r)
+	}
+}
+
+func (rt route) matches(p string) bool {
+	if rt.prefix {
+		return strings.HasPrefix(p, rt.path)
+	}
+	return p == rt.path
+}
+
+func (rt route) allows(method string) bool {
+	if rt.methods == nil {
+		return true
+	}
+	for _, m := range rt.methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func handler(ctx context.Context, w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	defer assignRequestID(w, r)()
+	setCORSHeaders(w, r)
+
+	// A single trailing slash is ignored, so /ip/ is the same as /ip.
+	if p := r.URL.Path; trimTrailingSlash(p) {
+		requestURIs.Store(r, r.URL.RequestURI())
+		defer requestURIs.Delete(r)
+		r.URL.Path = strings.TrimSuffix(p, "/")
+		r.URL.RawPath = ""
+	}
+
+	for _, rt := range routes {
+		if !rt.matches(r.URL.Path) {
+			continue
+		}
+		if r.Method == "OPTIONS" {
+			handlePreflight(w, r, rt.methods)
+			return
+		}
+		if !rt.allows(r.Method) {
+			w.Header().Set("Allow", strings.Join(rt.methods, ", "))
+			fsthttp.Error(w, fsthttp.StatusText(405), fsthttp.StatusMethodNotAllowed)
+			return
+		}
+		if robotsDisallowed(r.URL.Path) {
+			w.Header().Set("X-Robots-Tag", "none")
+		}
+		if r.Method == "HEAD" {
+			hw := newHeadResponseWriter(w)
+			rt.handler(ctx, hw, r)
+			hw.finish()
+			return
+		}
+		rt.handler(ctx, w, r)
+		return
+	}
+
+	data, err := staticAssets.ReadFile(path.Join("static", strings.TrimLeft(r.URL.Path, "/")))
+	if err == nil {
+		w.Write(data)
+		return
+	}
+
+	// Catch all other requests and return a 404.
+	handleNotFound(w, r)
+}
+
+// handleRoutes lists the route table, for discovering endpoints without
+// scraping the index page. Routes taking any method list "*".
+func handleRoutes(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	type entry struct {
+		Path        string   `json:"path"`
+		Prefix      bool     `json:"prefix"`
+		Methods     []string `json:"methods"`
+		Description string   `json:"description"`
+		Example     string   `json:"example"`
+	}
+	list := make([]entry, 0, len(routes))
+	for _, rt := range routes {
+		e := entry{
+			Path:        rt.path,
+			Prefix:      rt.prefix,
+			Methods:     rt.methods,
+			Description: rt.description,
+			Example:     rt.example,
+		}
+		if e.Methods == nil {
+			e.Methods = []string{"*"}
+		}
+		if e.Example == "" {
+			e.Example = rt.path
+		}
+		list = append(list, e)
+	}
+	writeJSON(w, r, fsthttp.StatusOK, map[string]interface{}{"routes": list})
+}
+
+// handleNotFound returns a 404, as JSON for clients that prefer it.
+func handleNotFound(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "application/json") {
+		fsthttp.Error(w, fsthttp.StatusText(404), 404)
+		return
+	}
+
+	writeJSON(w, r, fsthttp.StatusNotFound, map[string]string{
+		"error": fsthttp.StatusText(404),
+		"path":  r.URL.Path,
+	})
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMethodNotAllowed(t *testing.T) {
+	tests := []struct {
+		method, target, allow string
+	}{
+		{"DELETE", "/bytes/10", "GET, HEAD"},
+		{"POST", "/status/200", "GET, HEAD"},
+		{"GET", "/post", "POST"},
+	}
+	for _, tt := range tests {
+		rec := serve(t, tt.method, tt.target, nil)
+		if rec.status != 405 {
+			t.Errorf("%s %s: status %d, want 405", tt.method, tt.target, rec.status)
+		}
+		if got := rec.header.Get("Allow"); got != tt.allow {
+			t.Errorf("%s %s: Allow %q, want %q", tt.method, tt.target, got, tt.allow)
+		}
+	}
+
+	for _, method := range []string{"GET", "POST", "PUT", "PATCH", "DELETE"} {
+		if rec := serve(t, method, "/anything", nil); rec.status != 200 {
+			t.Errorf("%s /anything: status %d, want 200", method, rec.status)
+		}
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	rec := serve(t, "GET", "/no/such/page", nil, "Accept", "application/json")
+	if rec.status != 404 || rec.header.Get("Content-Type") != "application/json; charset=utf-8" {
+		t.Errorf("JSON: status %d, Content-Type %q", rec.status, rec.header.Get("Content-Type"))
+	}
+	var body map[string]string
+	if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["error"] != "Not Found" || body["path"] != "/no/such/page" {
+		t.Errorf("JSON body %v", body)
+	}
+
+	rec = serve(t, "GET", "/no/such/page", nil)
+	if rec.status != 404 || !strings.HasPrefix(rec.header.Get("Content-Type"), "text/plain") {
+		t.Errorf("default: status %d, Content-Type %q", rec.status, rec.header.Get("Content-Type"))
+	}
+}
+
+func TestTrailingSlash(t *testing.T) {
+	for _, target := range []string{"/ip", "/status/201", "/anything/foo"} {
+		plain := serve(t, "GET", target, nil)
+		slashed := serve(t, "GET", target+"/", nil)
+		if plain.status != slashed.status {
+			t.Errorf("GET %s/: status %d, want %d", target, slashed.status, plain.status)
+		}
+		if plain.header.Get("Content-Type") != slashed.header.Get("Content-Type") {
+			t.Errorf("GET %s/: Content-Type %q, want %q", target, slashed.header.Get("Content-Type"), plain.header.Get("Content-Type"))
+		}
+	}
+	if rec := serve(t, "GET", "/", nil); rec.status != 200 || !strings.Contains(rec.body.String(), "<html") {
+		t.Errorf("GET /: status %d", rec.status)
+	}
+
+	// The bare prefix of a prefix route keeps its slash, so it still
+	// reaches that route rather than the one above it.
+	if rec := serve(t, "GET", "/cache/purgeable/", nil); rec.status != 400 || rec.body.String() != "Invalid key\n" {
+		t.Errorf("GET /cache/purgeable/: status %d, body %q, want the purgeable handler's 400", rec.status, rec.body.String())
+	}
+	if rec := serve(t, "GET", "/cache/purgeable/product/", nil); rec.status != 200 || rec.header.Get("Surrogate-Key") != "product" {
+		t.Errorf("GET /cache/purgeable/product/: status %d, Surrogate-Key %q", rec.status, rec.header.Get("Surrogate-Key"))
+	}
+}
+
+func TestRoutesList(t *testing.T) {
+	rec := serve(t, "GET", "/routes", nil)
+	var body struct {
+		Routes []struct {
+			Path    string   `json:"path"`
+			Prefix  bool     `json:"prefix"`
+			Methods []string `json:"methods"`
+			Example string   `json:"example"`
+		} `json:"routes"`
+	}
+	if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Routes) != len(routes) {
+		t.Errorf("%d routes listed, want %d", len(body.Routes), len(routes))
+	}
+
+	want := map[string]struct {
+		prefix  bool
+		methods string
+		example string
+	}{
+		"/anything": {false, "*", "/anything"},
+		"/post":     {false, "POST", "/post"},
+		"/status/":  {true, "GET,HEAD", "/status/418"},
+		"/routes":   {false, "GET,HEAD", "/routes"},
+	}
+	found := 0
+	for _, rt := range body.Routes {
+		w, ok := want[rt.Path]
+		if !ok {
+			continue
+		}
+		found++
+		if rt.Prefix != w.prefix || strings.Join(rt.Methods, ",") != w.methods || rt.Example != w.example {
+			t.Errorf("%s: prefix %v, methods %v, example %q", rt.Path, rt.Prefix, rt.Methods, rt.Example)
+		}
+	}
+	if found != len(want) {
+		t.Errorf("found %d of the %d expected routes", found, len(want))
+	}
+}
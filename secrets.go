@@ -0,0 +1,61 @@
+package main
+
+import (
+	"crypto/subtle"
+	"strings"
+	"sync"
+
+	"github.com/fastly/compute-sdk-go/secretstore"
+)
+
+// secretStoreName is the Fastly secret store holding values that shouldn't
+// be readable from the service configuration, such as signing keys.
+const secretStoreName = "edgehttpbin-secrets"
+
+var (
+	secretOnce  sync.Once
+	secretStore *secretstore.Store
+)
+
+// secretLookup reads the plaintext of the named secret from the secret
+// store. It's a variable so the store can be replaced outside of
+// Compute@Edge.
+var secretLookup = func(name string) ([]byte, bool) {
+	secretOnce.Do(func() {
+		secretStore, _ = secretstore.Open(secretStoreName)
+	})
+	if secretStore == nil {
+		return nil, false
+	}
+	s, err := secretStore.Get(name)
+	if err != nil {
+		return nil, false
+	}
+	v, err := s.Plaintext()
+	if err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// secretValue returns the plaintext of the named secret, if the secret store
+// exists and has it set.
+func secretValue(name string) ([]byte, bool) {
+	v, ok := secretLookup(name)
+	if !ok || len(v) == 0 {
+		return nil, false
+	}
+	return v, true
+}
+
+// secretTokenMatches reports whether tokens, one per line, has token. Each
+// is compared in constant time.
+func secretTokenMatches(tokens []byte, token string) bool {
+	found := false
+	for _, line := range strings.Split(string(tokens), "\n") {
+		if t := strings.TrimSpace(line); t != "" && subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			found = true
+		}
+	}
+	return found
+}
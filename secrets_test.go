@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+// fakeSecrets replaces the secret store with values for the rest of the test.
+func fakeSecrets(t *testing.T, values map[string]string) {
+	saved := secretLookup
+	t.Cleanup(func() { secretLookup = saved })
+	secretLookup = func(name string) ([]byte, bool) {
+		v, ok := values[name]
+		return []byte(v), ok
+	}
+}
+
+func TestSecretBasicAuth(t *testing.T) {
+	if rec := serve(t, "GET", "/basic-auth", nil, "Authorization", basicAuthorization("alice:hunter2")); rec.status != 503 {
+		t.Errorf("GET /basic-auth without basic_auth_users: status %d, want 503", rec.status)
+	}
+
+	fakeSecrets(t, map[string]string{"basic_auth_users": "alice:hunter2\n bob:correct:horse \n"})
+	tests := []struct {
+		credentials string
+		status      int
+	}{
+		{"alice:hunter2", 200},
+		{"bob:correct:horse", 200},
+		{"alice:wrong", 401},
+		{"carol:hunter2", 401},
+		{"alice:", 401},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", "/basic-auth", nil, "Authorization", basicAuthorization(tt.credentials))
+		if rec.status != tt.status {
+			t.Errorf("GET /basic-auth as %s: status %d, want %d", tt.credentials, rec.status, tt.status)
+		}
+	}
+	if rec := serve(t, "GET", "/hidden-basic-auth", nil, "Authorization", basicAuthorization("alice:wrong")); rec.status != 404 {
+		t.Errorf("GET /hidden-basic-auth as alice:wrong: status %d, want 404", rec.status)
+	}
+}
+
+func TestSecretBearerTokens(t *testing.T) {
+	fakeSecrets(t, map[string]string{"bearer_tokens": "first\nsecond\n"})
+	for token, status := range map[string]int{"first": 200, "second": 200, "third": 401, "firs": 401} {
+		rec := serve(t, "GET", "/bearer", nil, "Authorization", "Bearer "+token)
+		if rec.status != status {
+			t.Errorf("GET /bearer with %s: status %d, want %d", token, rec.status, status)
+		}
+		if status == 401 && rec.header.Get("WWW-Authenticate") != `Bearer error="invalid_token"` {
+			t.Errorf("GET /bearer with %s: WWW-Authenticate %q", token, rec.header.Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestSecretEmptyValue(t *testing.T) {
+	fakeSecrets(t, map[string]string{"bearer_tokens": ""})
+	if rec := serve(t, "GET", "/bearer", nil, "Authorization", "Bearer anything"); rec.status != 200 {
+		t.Errorf("GET /bearer with an empty bearer_tokens: status %d, want 200", rec.status)
+	}
+}
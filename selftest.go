@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// selfTestChecks are the requests replayed through the handler by
+// /self-test. They should stay cheap: no delays and no large bodies, and
+// nothing rate limited, or a busy client would make the service look down.
+var selfTestChecks = []struct {
+	path   string
+	status int
+}{
+	{"/status/200", fsthttp.StatusOK},
+	{"/uuid", fsthttp.StatusOK},
+	{"/cookies", fsthttp.StatusOK},
+	{"/user-agent", fsthttp.StatusOK},
+	{"/ip", fsthttp.StatusOK},
+	{"/cache/60", fsthttp.StatusOK},
+	{"/redirect/1", fsthttp.StatusFound},
+}
+
+type selfTestResult struct {
+	Path   string `json:"path"`
+	Status int    `json:"status"`
+	OK     bool   `json:"ok"`
+}
+
+func handleSelfTest(ctx context.Context, w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	healthy := true
+	results := make([]selfTestResult, 0, len(selfTestChecks))
+	for _, check := range selfTestChecks {
+		req, err := fsthttp.NewRequest("GET", check.path, nil)
+		if err != nil {
+			fsthttp.Error(w, err.Error(), fsthttp.StatusInternalServerError)
+			return
+		}
+		req.RemoteAddr = r.RemoteAddr
+		req.Header.Set("User-Agent", "edgehttpbin-self-test")
+
+		rec := newResponseRecorder()
+		handler(ctx, rec, req)
+
+		ok := rec.status == check.status
+		healthy = healthy && ok
+		results = append(results, selfTestResult{Path: check.path, Status: rec.status, OK: ok})
+	}
+
+	status := fsthttp.StatusOK
+	if !healthy {
+		status = fsthttp.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store, max-age=0")
+	writeJSON(w, r, status, struct {
+		Healthy bool             `json:"healthy"`
+		Checks  []selfTestResult `json:"checks"`
+	}{healthy, results})
+}
+
+// responseRecorder is an in-memory fsthttp.ResponseWriter used to run
+// requests through the handler without sending anything downstream.
+type responseRecorder struct {
+	once   sync.Once
+	header fsthttp.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{header: fsthttp.NewHeader()}
+}
+
+func (rec *responseRecorder) Header() fsthttp.Header {
+	return rec.header
+}
+
+func (rec *responseRecorder) WriteHeader(code int) {
+	rec.once.Do(func() {
+		rec.status = code
+	})
+}
+
+func (rec *responseRecorder) Write(p []byte) (int, error) {
+	rec.WriteHeader(fsthttp.StatusOK)
+	return rec.body.Write(p)
+}
+
+func (rec *responseRecorder) Close() error {
+	rec.WriteHeader(fsthttp.StatusOK)
+	return nil
+}
+
+func (rec *responseRecorder) SetManualFramingMode(bool) {}
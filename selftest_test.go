@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	rec := serve(t, "GET", "/self-test", nil)
+	var body struct {
+		Healthy bool             `json:"healthy"`
+		Checks  []selfTestResult `json:"checks"`
+	}
+	if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if rec.status != 200 || !body.Healthy {
+		t.Errorf("status %d, healthy %v, want 200 and healthy", rec.status, body.Healthy)
+	}
+	if len(body.Checks) != len(selfTestChecks) {
+		t.Fatalf("%d checks, want %d", len(body.Checks), len(selfTestChecks))
+	}
+	for _, c := range body.Checks {
+		if !c.OK {
+			t.Errorf("%s: status %d", c.Path, c.Status)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+const (
+	maxSSEEvents       = 100
+	maxSSEInterval     = 10 * time.Second
+	defaultSSEInterval = time.Second
+)
+
+// handleSSE streams n Server-Sent Events, interval apart. Writes go straight
+// to the client, so each event arrives as soon as it's written. The whole
+// stream must fit within maxDelay, like any other wait.
+func handleSSE(ctx context.Context, w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) != 3 {
+		fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
+		return
+	}
+
+	n, err := strconv.Atoi(parts[2])
+	if err != nil || n < 1 || n > maxSSEEvents {
+		fsthttp.Error(w, fmt.Sprintf("Invalid n, must be between 1 and %d", maxSSEEvents), fsthttp.StatusBadRequest)
+		return
+	}
+
+	interval := defaultSSEInterval
+	if param := r.URL.Query().Get("interval"); param != "" {
+		interval, err = parseBoundedDuration(param, 0, maxSSEInterval)
+		if err != nil {
+			fsthttp.Error(w, "Invalid interval", fsthttp.StatusBadRequest)
+			return
+		}
+	}
+	if time.Duration(n-1)*interval > maxDelay() {
+		fsthttp.Error(w, fmt.Sprintf("Invalid n and interval, the events must all be sent within %s", maxDelay()), fsthttp.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(fsthttp.StatusOK)
+
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+
+		data, _ := json.Marshal(struct {
+			ID        int    `json:"id"`
+			Timestamp string `json:"timestamp"`
+		}{i, time.Now().UTC().Format(time.RFC3339Nano)})
+		fmt.Fprintf(w, "id: %d\nevent: ping\ndata: %s\n\n", i, data)
+	}
+}
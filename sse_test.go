@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSSE(t *testing.T) {
+	rec := serve(t, "GET", "/sse/3?interval=0.01", nil)
+	if rec.status != 200 || rec.header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status %d, Content-Type %q", rec.status, rec.header.Get("Content-Type"))
+	}
+	if got := strings.Count(rec.body.String(), "\ndata: "); got != 3 {
+		t.Errorf("%d events, want 3:\n%s", got, rec.body.String())
+	}
+	if !strings.HasPrefix(rec.body.String(), "id: 0\nevent: ping\ndata: {") {
+		t.Errorf("first event %q", rec.body.String())
+	}
+}
+
+func TestSSEBounded(t *testing.T) {
+	fakeConfig(t, map[string]string{"delay_max": "1"})
+	tests := []struct {
+		target string
+		status int
+	}{
+		{"/sse/0", 400},
+		{"/sse/101", 400},
+		{"/sse/2?interval=11", 400},
+		{"/sse/3?interval=1", 400},
+		{"/sse/11?interval=0.1", 200},
+		{"/sse/12?interval=0.1", 400},
+	}
+	for _, tt := range tests {
+		if rec := serve(t, "GET", tt.target, nil); rec.status != tt.status {
+			t.Errorf("GET %s: status %d, want %d", tt.target, rec.status, tt.status)
+		}
+	}
+}
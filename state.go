@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fastly/compute-sdk-go/objectstore"
+)
+
+// stateStoreName is the Fastly object store holding state that must outlive
+// a single request. Each request runs in a fresh instance on Compute@Edge,
+// so without the store state only lasts for the current execution.
+const stateStoreName = "edgehttpbin-state"
+
+var (
+	stateOnce  sync.Once
+	stateStore *objectstore.Store
+
+	// localState backs the state helpers when the object store is missing.
+	localMu    sync.Mutex
+	localState = map[string]string{}
+)
+
+func openStateStore() *objectstore.Store {
+	stateOnce.Do(func() {
+		stateStore, _ = objectstore.Open(stateStoreName)
+	})
+	return stateStore
+}
+
+// loadState returns the value stored under key, unless it has expired.
+func loadState(key string) (string, bool) {
+	if store := openStateStore(); store != nil {
+		entry, err := store.Lookup(key)
+		if err != nil {
+			return "", false
+		}
+		return unwrapState(entry.String())
+	}
+
+	localMu.Lock()
+	defer localMu.Unlock()
+	v, ok := unwrapState(localState[key])
+	if !ok {
+		delete(localState, key)
+	}
+	return v, ok
+}
+
+// storeState saves value under key for ttl. The object store can't expire
+// or delete entries itself, so the expiry is saved with the value as
+// "expiry value" and loadState treats anything past it as missing. Storing
+// with a ttl of zero is the nearest thing to deleting an entry.
+func storeState(key, value string, ttl time.Duration) error {
+	value = strconv.FormatInt(time.Now().Add(ttl).UnixNano(), 10) + " " + value
+	if store := openStateStore(); store != nil {
+		return store.Insert(key, strings.NewReader(value))
+	}
+
+	localMu.Lock()
+	defer localMu.Unlock()
+	localState[key] = value
+	return nil
+}
+
+// unwrapState splits a stored entry into its value, reporting false if the
+// entry has expired or isn't in the "expiry value" form.
+func unwrapState(entry string) (string, bool) {
+	parts := strings.SplitN(entry, " ", 2)
+	if len(parts) != 2 {
+		return "", false
+	}
+	expiry, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || time.Now().UnixNano() >= expiry {
+		return "", false
+	}
+	return parts[1], true
+}
+
+var incrementMu sync.Mutex
+
+// incrementCounter adds one to the counter under key and returns the new
+// value. The counter is forgotten once ttl passes without an increment. The
+// object store has no atomic increment, so concurrent requests may observe
+// the same value; sequential callers always see it advance.
+func incrementCounter(key string, ttl time.Duration) (int64, error) {
+	incrementMu.Lock()
+	defer incrementMu.Unlock()
+
+	var n int64
+	if v, ok := loadState(key); ok {
+		n, _ = strconv.ParseInt(v, 10, 64)
+	}
+	n++
+	return n, storeState(key, strconv.FormatInt(n, 10), ttl)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStateExpiry(t *testing.T) {
+	const key = "test/state-expiry"
+	if err := storeState(key, "a value", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := loadState(key); !ok || v != "a value" {
+		t.Errorf("loadState = %q, %v, want the stored value", v, ok)
+	}
+
+	storeState(key, "gone", 0)
+	if v, ok := loadState(key); ok {
+		t.Errorf("loadState = %q after expiry, want nothing", v)
+	}
+	localMu.Lock()
+	_, kept := localState[key]
+	localMu.Unlock()
+	if kept {
+		t.Error("expired entry was kept in local state")
+	}
+}
+
+func TestIncrementCounterExpiry(t *testing.T) {
+	const key = "test/counter-expiry"
+	for want := int64(1); want <= 2; want++ {
+		if n, err := incrementCounter(key, time.Minute); err != nil || n != want {
+			t.Fatalf("incrementCounter = %d, %v, want %d", n, err, want)
+		}
+	}
+	incrementCounter(key, 0)
+	if n, _ := incrementCounter(key, time.Minute); n != 1 {
+		t.Errorf("after expiry: count %d, want 1", n)
+	}
+}
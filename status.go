@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+func handleStatus(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) != 3 {
+		fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
+		return
+	}
+	codes, err := parseWeightedCodes(parts[2])
+	if err != nil {
+		fsthttp.Error(w, "Invalid status", fsthttp.StatusBadRequest)
+		return
+	}
+	if len(codes) > 1 {
+		// Don't cache for random responses
+		w.Header().Add("Surrogate-Control", "max-age=31557600")
+		w.Header().Add("Cache-Control", "no-store, max-age=0")
+	}
+	retryAfter := defaultRetryAfter
+	if param := r.URL.Query().Get("retry-after"); param != "" {
+		retryAfter, err = strconv.Atoi(param)
+		if err != nil || retryAfter < 0 {
+			fsthttp.Error(w, "Invalid retry-after, must be a non-negative integer", fsthttp.StatusBadRequest)
+			return
+		}
+	}
+
+	// ?header=Name:value adds a response header, and may be repeated. They
+	// go on last so they can replace the headers set below.
+	var headers [][2]string
+	for _, header := range r.URL.Query()["header"] {
+		kv := strings.SplitN(header, ":", 2)
+		name := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || name == "" || !validHeaderValue(header) {
+			fsthttp.Error(w, "Invalid header, must be Name:value without control characters", fsthttp.StatusBadRequest)
+			return
+		}
+		if forbiddenResponseHeaders[fsthttp.CanonicalHeaderKey(name)] {
+			fsthttp.Error(w, fmt.Sprintf("header %s can't be set", name), fsthttp.StatusBadRequest)
+			return
+		}
+		headers = append(headers, [2]string{name, strings.TrimSpace(kv[1])})
+	}
+
+	code := pickWeightedCode(codes)
+	if code == fsthttp.StatusTooManyRequests || code == fsthttp.StatusServiceUnavailable {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	}
+	body := r.URL.Query().Get("body")
+	if body != "" {
+		contentType := r.URL.Query().Get("content-type")
+		if contentType == "" {
+			contentType = "text/plain; charset=utf-8"
+		}
+		w.Header().Set("Content-Type", contentType)
+	} else if code >= 300 {
+		// As fsthttp.Error, but leaving room for the ?header= headers.
+		body = fsthttp.StatusText(code) + "\n"
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+	}
+	for _, header := range headers {
+		w.Header().Del(header[0])
+	}
+	for _, header := range headers {
+		w.Header().Add(header[0], header[1])
+	}
+	w.WriteHeader(code)
+	if body != "" {
+		w.Write([]byte(body))
+	}
+}
+
+// defaultRetryAfter is the Retry-After, in seconds, sent with a 429 or 503
+// when the request doesn't ask for one.
+const defaultRetryAfter = 5
+
+type weightedCode struct {
+	code   int
+	weight float64
+}
+
+// parseWeightedCodes parses a comma separated list of code[:weight]
+// entries, such as 200:3,500:1 or 200:0.9,500:0.1. Weights are relative, so
+// they needn't add up to 1, and entries without one default to 1.
+func parseWeightedCodes(input string) ([]weightedCode, error) {
+	var codes []weightedCode
+	for _, entry := range strings.Split(input, ",") {
+		codeStr, weightStr := entry, ""
+		if i := strings.IndexByte(entry, ':'); i >= 0 {
+			codeStr, weightStr = entry[:i], entry[i+1:]
+		}
+
+		code, err := strconv.Atoi(codeStr)
+		if err != nil {
+			return nil, err
+		}
+		if code < 100 || code > 999 {
+			return nil, fmt.Errorf("invalid status code %d", code)
+		}
+
+		weight := 1.0
+		if weightStr != "" {
+			weight, err = strconv.ParseFloat(weightStr, 64)
+			if err != nil {
+				return nil, err
+			}
+			if !(weight > 0) || weight > 1e9 {
+				return nil, fmt.Errorf("invalid weight %s", weightStr)
+			}
+		}
+		codes = append(codes, weightedCode{code, weight})
+	}
+	return codes, nil
+}
+
+func pickWeightedCode(codes []weightedCode) int {
+	var total float64
+	for _, c := range codes {
+		total += c.weight
+	}
+	n := rand.Float64() * total
+	for _, c := range codes {
+		if n < c.weight {
+			return c.code
+		}
+		n -= c.weight
+	}
+	return codes[len(codes)-1].code
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStatusWeights(t *testing.T) {
+	const n = 4000
+	counts := map[int]int{}
+	for i := 0; i < n; i++ {
+		rec := serve(t, "GET", "/status/200:3,500:1", nil)
+		counts[rec.status]++
+	}
+	if len(counts) != 2 {
+		t.Fatalf("got statuses %v, want only 200 and 500", counts)
+	}
+	// Three standard deviations is about 0.02 at this many draws.
+	if ratio := float64(counts[200]) / n; math.Abs(ratio-0.75) > 0.03 {
+		t.Errorf("200 chosen %.3f of the time, want about 0.75", ratio)
+	}
+
+	rec := serve(t, "GET", "/status/200:3,500:1", nil)
+	if got := rec.header.Get("Cache-Control"); got != "no-store, max-age=0" {
+		t.Errorf("Cache-Control %q, want no-store", got)
+	}
+}
+
+func TestParseWeightedCodes(t *testing.T) {
+	for _, input := range []string{"200:0", "200:-1", "200:x", "99", "1000", "200,,500"} {
+		if _, err := parseWeightedCodes(input); err == nil {
+			t.Errorf("parseWeightedCodes(%q) succeeded", input)
+		}
+	}
+	codes, err := parseWeightedCodes("200:0.9,500")
+	if err != nil || len(codes) != 2 || codes[0] != (weightedCode{200, 0.9}) || codes[1] != (weightedCode{500, 1}) {
+		t.Errorf("parseWeightedCodes(200:0.9,500) = %v, %v", codes, err)
+	}
+}
+
+func TestStatusRetryAfter(t *testing.T) {
+	tests := []struct {
+		target, want string
+	}{
+		{"/status/503?retry-after=10", "10"},
+		{"/status/429", "5"},
+		{"/status/500?retry-after=10", ""},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", tt.target, nil)
+		if got := rec.header.Get("Retry-After"); got != tt.want {
+			t.Errorf("GET %s: Retry-After %q, want %q", tt.target, got, tt.want)
+		}
+	}
+	if rec := serve(t, "GET", "/status/503?retry-after=-1", nil); rec.status != 400 {
+		t.Errorf("negative retry-after: status %d, want 400", rec.status)
+	}
+}
+
+func TestStatusBody(t *testing.T) {
+	rec := serve(t, "GET", `/status/404?body={"error":"missing"}&content-type=application/json`, nil)
+	if rec.status != 404 {
+		t.Errorf("status %d, want 404", rec.status)
+	}
+	if got := rec.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type %q, want application/json", got)
+	}
+	if got := rec.body.String(); got != `{"error":"missing"}` {
+		t.Errorf("body %q", got)
+	}
+
+	rec = serve(t, "GET", "/status/500?body=oops", nil)
+	if rec.status != 500 || rec.body.String() != "oops" || rec.header.Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Errorf("plain body: status %d, Content-Type %q, body %q", rec.status, rec.header.Get("Content-Type"), rec.body.String())
+	}
+}
+
+func TestStatusHeaderControlCharacters(t *testing.T) {
+	for _, header := range []string{"X-Foo:bar%0d%0aSet-Cookie:%20x=1", "X-Foo:bar%0a", "X-Foo%0d:bar"} {
+		rec := serve(t, "GET", "/status/200?header="+header, nil)
+		if rec.status != 400 || rec.header.Get("X-Foo") != "" || rec.header.Get("Set-Cookie") != "" {
+			t.Errorf("header=%s: status %d, want 400 and no headers set", header, rec.status)
+		}
+	}
+}
+
+func TestStatusHeader(t *testing.T) {
+	tests := []struct {
+		target, name, want string
+	}{
+		{"/status/200?header=X-Foo:bar", "X-Foo", "bar"},
+		{"/status/404?header=X-Foo:%20bar%20", "X-Foo", "bar"},
+		{"/status/404?header=Content-Type:application/problem%2Bjson", "Content-Type", "application/problem+json"},
+		{"/status/500?body=oops&header=Content-Type:text/html", "Content-Type", "text/html"},
+		{"/status/429?header=Retry-After:60", "Retry-After", "60"},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", tt.target, nil)
+		if got := rec.header.Values(tt.name); len(got) != 1 || got[0] != tt.want {
+			t.Errorf("GET %s: %s %q, want %q", tt.target, tt.name, got, tt.want)
+		}
+	}
+
+	rec := serve(t, "GET", "/status/200?header=X-Foo:a&header=X-Foo:b", nil)
+	if got := rec.header.Values("X-Foo"); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("repeated header: X-Foo %q, want [a b]", got)
+	}
+
+	rec = serve(t, "GET", "/status/200?header=Content-Length:5", nil)
+	if rec.status != 400 || rec.header.Get("Content-Length") != "" {
+		t.Errorf("forbidden header: status %d, Content-Length %q, want 400 and unset", rec.status, rec.header.Get("Content-Length"))
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+const (
+	maxStreamLines    = 100
+	maxStreamInterval = 10 * time.Second
+)
+
+// streamLine is a line of /stream: the request plus its position.
+type streamLine struct {
+	requestInfo
+	ID int `json:"id"`
+}
+
+// handleStream writes min(n, 100) newline delimited JSON objects, each the
+// request plus its id. Every line is its own write, so it reaches the client
+// as it's produced rather than in one buffered body.
+//
+// ?interval= spaces the lines out, sending a ": keepalive" comment line at
+// the start of each gap, as long as the whole stream fits within maxDelay.
+// ?fail-at= stops after that many lines with a malformed final line, since a
+// Compute@Edge program can't reset the connection, so clients can exercise
+// their handling of broken streams. The response still ends cleanly, so the
+// X-Stream-Fail-At header announces the truncation up front.
+func handleStream(ctx context.Context, w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) != 3 {
+		fsthttp.Error(w, "Not found", fsthttp.StatusNotFound)
+		return
+	}
+
+	n, err := strconv.Atoi(parts[2])
+	if err != nil || n < 1 {
+		fsthttp.Error(w, "Invalid n, must be a positive integer", fsthttp.StatusBadRequest)
+		return
+	}
+	if n > maxStreamLines {
+		n = maxStreamLines
+	}
+
+	query := r.URL.Query()
+	var interval time.Duration
+	if param := query.Get("interval"); param != "" {
+		interval, err = parseBoundedDuration(param, 0, maxStreamInterval)
+		if err != nil {
+			fsthttp.Error(w, "Invalid interval", fsthttp.StatusBadRequest)
+			return
+		}
+	}
+	if time.Duration(n-1)*interval > maxDelay() {
+		fsthttp.Error(w, fmt.Sprintf("Invalid n and interval, the lines must all be sent within %s", maxDelay()), fsthttp.StatusBadRequest)
+		return
+	}
+	failAt := -1
+	if param := query.Get("fail-at"); param != "" {
+		failAt, err = strconv.Atoi(param)
+		if err != nil || failAt < 0 || failAt > n {
+			fsthttp.Error(w, fmt.Sprintf("Invalid fail-at, must be between 0 and %d", n), fsthttp.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if failAt >= 0 {
+		w.Header().Set("X-Stream-Fail-At", strconv.Itoa(failAt))
+	}
+	w.WriteHeader(fsthttp.StatusOK)
+
+	info := newRequestInfo(r)
+	for i := 0; i < n; i++ {
+		if i > 0 && interval > 0 {
+			w.Write([]byte(": keepalive\n"))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+		if i == failAt {
+			break
+		}
+
+		line, err := json.Marshal(streamLine{info, i})
+		if err != nil {
+			return
+		}
+		w.Write(append(line, '\n'))
+	}
+	if failAt >= 0 {
+		w.Write([]byte(`{"id":`))
+	}
+}
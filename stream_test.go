@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	rec := serve(t, "GET", "/stream/3", nil)
+	if rec.status != 200 || rec.header.Get("X-Stream-Fail-At") != "" {
+		t.Fatalf("status %d, X-Stream-Fail-At %q", rec.status, rec.header.Get("X-Stream-Fail-At"))
+	}
+	lines := strings.Split(strings.TrimSuffix(rec.body.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("%d lines, want 3", len(lines))
+	}
+	for i, line := range lines {
+		var got streamLine
+		if err := json.Unmarshal([]byte(line), &got); err != nil || got.ID != i {
+			t.Errorf("line %d: %q", i, line)
+		}
+	}
+
+	if rec := serve(t, "GET", "/stream/500", nil); strings.Count(rec.body.String(), "\n") != maxStreamLines {
+		t.Errorf("/stream/500: %d lines, want %d", strings.Count(rec.body.String(), "\n"), maxStreamLines)
+	}
+}
+
+func TestStreamFailAt(t *testing.T) {
+	rec := serve(t, "GET", "/stream/5?fail-at=2", nil)
+	if got := rec.header.Get("X-Stream-Fail-At"); got != "2" {
+		t.Errorf("X-Stream-Fail-At %q, want 2", got)
+	}
+	lines := strings.Split(rec.body.String(), "\n")
+	if len(lines) != 3 || lines[2] != `{"id":` {
+		t.Errorf("body %q, want two lines and a broken third", rec.body.String())
+	}
+	if rec := serve(t, "GET", "/stream/5?fail-at=6", nil); rec.status != 400 {
+		t.Errorf("fail-at past n: status %d, want 400", rec.status)
+	}
+}
+
+func TestStreamBounded(t *testing.T) {
+	fakeConfig(t, map[string]string{"delay_max": "1"})
+	tests := []struct {
+		target string
+		status int
+	}{
+		{"/stream/0", 400},
+		{"/stream/2?interval=11", 400},
+		{"/stream/3?interval=1", 400},
+		{"/stream/3?interval=0.01", 200},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", tt.target, nil)
+		if rec.status != tt.status {
+			t.Errorf("GET %s: status %d, want %d", tt.target, rec.status, tt.status)
+		}
+		if rec.status == 200 && strings.Count(rec.body.String(), ": keepalive\n") != 2 {
+			t.Errorf("GET %s: body %q, want two keepalives", tt.target, rec.body.String())
+		}
+	}
+}
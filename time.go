@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"time"
+	_ "time/tzdata" // Compute@Edge has no zoneinfo to load ?tz= from.
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+type timeInfo struct {
+	Unix       int64  `json:"unix"`
+	UnixMillis int64  `json:"unix_millis"`
+	RFC3339    string `json:"rfc3339"`
+	RFC1123    string `json:"rfc1123"`
+	HTTPDate   string `json:"http_date"`
+	Timezone   string `json:"timezone"`
+}
+
+// handleTime returns the current edge time, formatted in the IANA timezone
+// given by ?tz= or UTC.
+func handleTime(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	loc := time.UTC
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			fsthttp.Error(w, "Invalid tz, must be an IANA timezone name", fsthttp.StatusBadRequest)
+			return
+		}
+	}
+
+	now := time.Now().In(loc)
+	w.Header().Set("Cache-Control", "no-store, max-age=0")
+	writeJSON(w, r, fsthttp.StatusOK, timeInfo{
+		Unix:       now.Unix(),
+		UnixMillis: now.UnixNano() / int64(time.Millisecond),
+		RFC3339:    now.Format(time.RFC3339),
+		RFC1123:    now.Format(time.RFC1123),
+		HTTPDate:   now.UTC().Format(http.TimeFormat),
+		Timezone:   loc.String(),
+	})
+}
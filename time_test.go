@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeZone(t *testing.T) {
+	tests := []struct {
+		tz, zone, offset string
+	}{
+		{"", "UTC", "Z"},
+		{"Asia/Kolkata", "Asia/Kolkata", "+05:30"},
+		{"Asia/Tokyo", "Asia/Tokyo", "+09:00"},
+	}
+	for _, tt := range tests {
+		rec := serve(t, "GET", "/time?tz="+tt.tz, nil)
+		if rec.status != 200 {
+			t.Fatalf("tz %q: status %d", tt.tz, rec.status)
+		}
+		var got timeInfo
+		if err := json.Unmarshal(rec.body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Timezone != tt.zone || !strings.HasSuffix(got.RFC3339, tt.offset) {
+			t.Errorf("tz %q: timezone %q, rfc3339 %q", tt.tz, got.Timezone, got.RFC3339)
+		}
+		parsed, err := time.Parse(time.RFC3339, got.RFC3339)
+		if err != nil || parsed.Unix() != got.Unix {
+			t.Errorf("tz %q: rfc3339 %q doesn't match unix %d", tt.tz, got.RFC3339, got.Unix)
+		}
+		if _, err := http.ParseTime(got.HTTPDate); err != nil || !strings.HasSuffix(got.HTTPDate, "GMT") {
+			t.Errorf("tz %q: http_date %q", tt.tz, got.HTTPDate)
+		}
+	}
+
+	if rec := serve(t, "GET", "/time?tz=Mars/Olympus", nil); rec.status != 400 {
+		t.Errorf("unknown tz: status %d, want 400", rec.status)
+	}
+}
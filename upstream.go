@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// sendUpstream sends req to a Fastly backend. It's a variable so the
+// subrequest can be replaced where there is no backend to reach.
+var sendUpstream = func(ctx context.Context, req *fsthttp.Request, backend string) (*fsthttp.Response, error) {
+	return req.Send(ctx, backend)
+}
+
+// proxyBackends returns the hosts /proxy may reach, mapped to the Fastly
+// backend serving each. They come from the proxy_backends config key, a
+// comma separated list of host=backend pairs. Reaching any other host would
+// turn the service into an open proxy, so without the key /proxy is off.
+func proxyBackends() map[string]string {
+	backends := map[string]string{}
+	v, _ := configValue("proxy_backends")
+	for _, pair := range strings.Split(v, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) == 2 && kv[0] != "" && kv[1] != "" {
+			backends[strings.ToLower(kv[0])] = kv[1]
+		}
+	}
+	return backends
+}
+
+// handleProxy forwards the request to ?url= and streams the response back
+// with its status and end-to-end headers.
+func handleProxy(ctx context.Context, w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	target, err := url.Parse(r.URL.Query().Get("url"))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		fsthttp.Error(w, "Invalid url, must be an absolute http or https URL", fsthttp.StatusBadRequest)
+		return
+	}
+	backend, ok := proxyBackends()[strings.ToLower(target.Hostname())]
+	if !ok {
+		fsthttp.Error(w, "Host not allowed", fsthttp.StatusForbidden)
+		return
+	}
+
+	req, err := fsthttp.NewRequest(r.Method, target.String(), r.Body)
+	if err != nil {
+		fsthttp.Error(w, err.Error(), fsthttp.StatusBadRequest)
+		return
+	}
+	for key, values := range r.Header {
+		if forbiddenResponseHeaders[fsthttp.CanonicalHeaderKey(key)] {
+			continue
+		}
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	resp, err := sendUpstream(ctx, req, backend)
+	if err != nil {
+		fsthttp.Error(w, err.Error(), fsthttp.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	for key, values := range resp.Header {
+		if forbiddenResponseHeaders[fsthttp.CanonicalHeaderKey(key)] {
+			continue
+		}
+		w.Header().Del(key)
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
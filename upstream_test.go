@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// upstreamCall is what a faked sendUpstream was asked to send.
+type upstreamCall struct {
+	req     *fsthttp.Request
+	backend string
+	body    string
+}
+
+// fakeUpstream replaces sendUpstream for the rest of the test with one that
+// records each request and answers with resp, or fails if resp is nil.
+func fakeUpstream(t *testing.T, resp func() *fsthttp.Response) *[]upstreamCall {
+	var calls []upstreamCall
+	saved := sendUpstream
+	t.Cleanup(func() { sendUpstream = saved })
+	sendUpstream = func(ctx context.Context, req *fsthttp.Request, backend string) (*fsthttp.Response, error) {
+		var body []byte
+		if req.Body != nil {
+			body, _ = io.ReadAll(req.Body)
+		}
+		calls = append(calls, upstreamCall{req, backend, string(body)})
+		if resp == nil {
+			return nil, errors.New("backend unreachable")
+		}
+		return resp(), nil
+	}
+	return &calls
+}
+
+func TestProxyForwards(t *testing.T) {
+	fakeConfig(t, map[string]string{"proxy_backends": "example.com=origin_0, other.test=origin_1"})
+	calls := fakeUpstream(t, func() *fsthttp.Response {
+		header := fsthttp.NewHeader()
+		header.Set("Content-Type", "text/plain")
+		header.Set("X-Upstream", "yes")
+		header.Set("Connection", "close")
+		return &fsthttp.Response{StatusCode: 201, Header: header, Body: io.NopCloser(strings.NewReader("from upstream"))}
+	})
+
+	target := "/proxy?url=" + url.QueryEscape("https://Example.com/path?q=1")
+	rec := serve(t, "POST", target, strings.NewReader("payload"),
+		"X-Custom", "kept", "Connection", "keep-alive", "Proxy-Authorization", "Basic Zm9vOmJhcg==")
+	if rec.status != 201 || rec.body.String() != "from upstream" {
+		t.Fatalf("status %d, body %q", rec.status, rec.body.String())
+	}
+	if rec.header.Get("X-Upstream") != "yes" || rec.header.Get("Connection") != "" {
+		t.Errorf("response headers %v", rec.header)
+	}
+
+	if len(*calls) != 1 {
+		t.Fatalf("%d upstream requests, want 1", len(*calls))
+	}
+	call := (*calls)[0]
+	if call.backend != "origin_0" || call.req.Method != "POST" || call.req.URL.String() != "https://Example.com/path?q=1" {
+		t.Errorf("sent %s %s to %s", call.req.Method, call.req.URL, call.backend)
+	}
+	if call.body != "payload" {
+		t.Errorf("forwarded body %q", call.body)
+	}
+	if call.req.Header.Get("X-Custom") != "kept" {
+		t.Error("X-Custom was not forwarded")
+	}
+	for _, name := range []string{"Connection", "Proxy-Authorization"} {
+		if call.req.Header.Get(name) != "" {
+			t.Errorf("%s was forwarded", name)
+		}
+	}
+}
+
+func TestProxyRejects(t *testing.T) {
+	fakeConfig(t, map[string]string{"proxy_backends": "example.com=origin_0"})
+	calls := fakeUpstream(t, nil)
+
+	tests := []struct {
+		url    string
+		status int
+	}{
+		{"https://evil.test/", 403},
+		{"https://example.com.evil.test/", 403},
+		{"ftp://example.com/", 400},
+		{"/relative", 400},
+		{"https://example.com/", 502},
+	}
+	for _, tt := range tests {
+		if rec := serve(t, "GET", "/proxy?url="+url.QueryEscape(tt.url), nil); rec.status != tt.status {
+			t.Errorf("url %s: status %d, want %d", tt.url, rec.status, tt.status)
+		}
+	}
+	if len(*calls) != 1 {
+		t.Errorf("%d upstream requests, want only the allowed one", len(*calls))
+	}
+}
+
+func TestProxyOff(t *testing.T) {
+	fakeConfig(t, nil)
+	calls := fakeUpstream(t, nil)
+	if rec := serve(t, "GET", "/proxy?url="+url.QueryEscape("https://example.com/"), nil); rec.status != 403 {
+		t.Errorf("status %d, want 403 without proxy_backends", rec.status)
+	}
+	if len(*calls) != 0 {
+		t.Error("sent a request without proxy_backends set")
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// handleUUID returns a random version 4 UUID, or with ?version=7 a version 7
+// one, which starts with the Unix time in milliseconds so they sort by when
+// they were made. See RFC 9562.
+func handleUUID(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		fsthttp.Error(w, err.Error(), fsthttp.StatusInternalServerError)
+		return
+	}
+
+	switch version := r.URL.Query().Get("version"); version {
+	case "", "4":
+		u[6] = u[6]&0x0f | 0x40
+	case "7":
+		var ms [8]byte
+		binary.BigEndian.PutUint64(ms[:], uint64(time.Now().UnixMilli()))
+		copy(u[:6], ms[2:])
+		u[6] = u[6]&0x0f | 0x70
+	default:
+		fsthttp.Error(w, "Invalid version, must be 4 or 7", fsthttp.StatusBadRequest)
+		return
+	}
+	// The RFC 4122 variant.
+	u[8] = u[8]&0x3f | 0x80
+
+	w.Header().Set("Cache-Control", "no-store, max-age=0")
+	writeJSON(w, r, fsthttp.StatusOK, map[string]string{
+		"uuid": fmt.Sprintf("%x-%x-%x-%x-%x", u[:4], u[4:6], u[6:8], u[8:10], u[10:]),
+	})
+}
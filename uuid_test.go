@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// getUUID fetches target and returns the bytes of the UUID it returns.
+func getUUID(t *testing.T, target string) (string, []byte) {
+	t.Helper()
+	rec := serve(t, "GET", target, nil)
+	if rec.status != 200 {
+		t.Fatalf("GET %s: status %d, want 200", target, rec.status)
+	}
+	var body struct {
+		UUID string `json:"uuid"`
+	}
+	if err := json.Unmarshal(rec.body.Bytes(), &body); err != nil {
+		t.Fatalf("GET %s: %v", target, err)
+	}
+	u, err := hex.DecodeString(strings.ReplaceAll(body.UUID, "-", ""))
+	if err != nil || len(u) != 16 || len(body.UUID) != 36 {
+		t.Fatalf("GET %s: malformed UUID %q", target, body.UUID)
+	}
+	return body.UUID, u
+}
+
+func TestUUIDVersions(t *testing.T) {
+	for target, version := range map[string]byte{"/uuid": 4, "/uuid?version=4": 4, "/uuid?version=7": 7} {
+		s, u := getUUID(t, target)
+		if got := u[6] >> 4; got != version {
+			t.Errorf("GET %s: %s has version %d, want %d", target, s, got, version)
+		}
+		if got := u[8] >> 6; got != 0x2 {
+			t.Errorf("GET %s: %s has variant bits %02b, want 10", target, s, got)
+		}
+	}
+	if rec := serve(t, "GET", "/uuid?version=1", nil); rec.status != 400 {
+		t.Errorf("GET /uuid?version=1: status %d, want 400", rec.status)
+	}
+}
+
+func TestUUIDv7(t *testing.T) {
+	s, u := getUUID(t, "/uuid?version=7")
+	var ms int64
+	for _, b := range u[:6] {
+		ms = ms<<8 | int64(b)
+	}
+	if d := time.Now().UnixMilli() - ms; d < 0 || d > 1000 {
+		t.Errorf("%s: timestamp %d is %dms from now", s, ms, d)
+	}
+
+	// Within a millisecond the order is random, so step past it.
+	prev := s
+	for i := 0; i < 5; i++ {
+		time.Sleep(2 * time.Millisecond)
+		next, _ := getUUID(t, "/uuid?version=7")
+		if next <= prev {
+			t.Errorf("%s issued after %s sorts before it", next, prev)
+		}
+		prev = next
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// version is the deployed build, set at build time with
+// -ldflags "-X main.version=...".
+var version = "dev"
+
+const sdkModule = "github.com/fastly/compute-sdk-go"
+
+type versionInfo struct {
+	Version   string `json:"version"`
+	Toolchain string `json:"toolchain"`
+	Compiler  string `json:"compiler"`
+	SDK       string `json:"sdk"`
+	Protocol  string `json:"protocol"`
+}
+
+func handleVersion(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	w.Header().Set("Cache-Control", "no-store, max-age=0")
+	writeJSON(w, r, fsthttp.StatusOK, versionInfo{
+		Version:   version,
+		Toolchain: runtime.Version(),
+		Compiler:  runtime.Compiler,
+		SDK:       sdkVersion(),
+		Protocol:  r.Proto,
+	})
+}
+
+// sdkVersion returns the compute-sdk-go version the binary was built with,
+// or "unknown" where the toolchain doesn't embed build information.
+func sdkVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == sdkModule {
+			return dep.Version
+		}
+	}
+	return "unknown"
+}
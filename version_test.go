@@ -0,0 +1,25 @@
+package main
+
+import (
+	"encoding/json"
+	"runtime"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	saved := version
+	version = "1.2.3"
+	t.Cleanup(func() { version = saved })
+
+	rec := serve(t, "GET", "/version", nil)
+	if rec.status != 200 || rec.header.Get("Cache-Control") != "no-store, max-age=0" {
+		t.Fatalf("status %d, Cache-Control %q", rec.status, rec.header.Get("Cache-Control"))
+	}
+	var got versionInfo
+	if err := json.Unmarshal(rec.body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != "1.2.3" || got.Toolchain != runtime.Version() || got.Compiler != runtime.Compiler || got.SDK == "" {
+		t.Errorf("got %+v", got)
+	}
+}
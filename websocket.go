@@ -0,0 +1,20 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+// handleWebSocket is the place for a WebSocket echo, once the SDK can hand a
+// client connection over to one. compute-sdk-go has no way to upgrade a
+// request yet, so an upgrade gets a 501 that clients can use to detect the
+// missing capability, and a plain request gets a 426 naming the upgrade.
+func handleWebSocket(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		w.Header().Set("Upgrade", "websocket")
+		fsthttp.Error(w, "This endpoint requires a WebSocket upgrade", fsthttp.StatusUpgradeRequired)
+		return
+	}
+	fsthttp.Error(w, "WebSocket upgrades are not supported by this service", fsthttp.StatusNotImplemented)
+}
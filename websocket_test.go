@@ -0,0 +1,15 @@
+package main
+
+import "testing"
+
+func TestWebSocket(t *testing.T) {
+	rec := serve(t, "GET", "/ws", nil)
+	if rec.status != 426 || rec.header.Get("Upgrade") != "websocket" {
+		t.Errorf("plain request: status %d, Upgrade %q, want 426 and websocket", rec.status, rec.header.Get("Upgrade"))
+	}
+
+	rec = serve(t, "GET", "/ws", nil, "Connection", "Upgrade", "Upgrade", "WebSocket", "Sec-WebSocket-Version", "13")
+	if rec.status != 501 {
+		t.Errorf("upgrade: status %d, want 501", rec.status)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+
+	"github.com/fastly/compute-sdk-go/fsthttp"
+)
+
+const maxXMLNodes = 100000
+
+// handleXML returns the sample document, or with ?nodes= a document with
+// that many child elements. The generated document is written out in
+// buffered chunks as it's generated, so it's never held in memory whole.
+func handleXML(w fsthttp.ResponseWriter, r *fsthttp.Request) {
+	param := r.URL.Query().Get("nodes")
+	if param == "" {
+		serveStatic(w, "sample.xml", "application/xml")
+		return
+	}
+
+	n, err := strconv.Atoi(param)
+	if err != nil || n < 0 || n > maxXMLNodes {
+		fsthttp.Error(w, fmt.Sprintf("Invalid nodes, must be between 0 and %d", maxXMLNodes), fsthttp.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(fsthttp.StatusOK)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<nodes count=\"%d\">\n", n)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(bw, "  <node id=\"%d\">Node %d</node>\n", i, i)
+	}
+	bw.WriteString("</nodes>\n")
+	bw.Flush()
+}
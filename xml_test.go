@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestXMLNodes(t *testing.T) {
+	for _, n := range []int{0, 1, 5000} {
+		rec := serve(t, "GET", "/xml?nodes="+strconv.Itoa(n), nil)
+		if rec.status != 200 || rec.header.Get("Content-Type") != "application/xml" {
+			t.Fatalf("nodes=%d: status %d, Content-Type %q", n, rec.status, rec.header.Get("Content-Type"))
+		}
+		var doc struct {
+			Count int `xml:"count,attr"`
+			Nodes []struct {
+				ID int `xml:"id,attr"`
+			} `xml:"node"`
+		}
+		if err := xml.Unmarshal(rec.body.Bytes(), &doc); err != nil {
+			t.Fatalf("nodes=%d: %v", n, err)
+		}
+		if doc.Count != n || len(doc.Nodes) != n {
+			t.Errorf("nodes=%d: count %d with %d nodes", n, doc.Count, len(doc.Nodes))
+		}
+		if n > 0 && doc.Nodes[n-1].ID != n-1 {
+			t.Errorf("nodes=%d: last id %d", n, doc.Nodes[n-1].ID)
+		}
+	}
+
+	for _, param := range []string{"-1", "100001", "many"} {
+		if rec := serve(t, "GET", "/xml?nodes="+param, nil); rec.status != 400 {
+			t.Errorf("nodes=%s: status %d, want 400", param, rec.status)
+		}
+	}
+	if rec := serve(t, "GET", "/xml", nil); rec.status != 200 || !strings.Contains(rec.body.String(), "<slideshow") {
+		t.Errorf("sample document: status %d", rec.status)
+	}
+}